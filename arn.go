@@ -0,0 +1,168 @@
+package secretlamb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	Resource  string
+}
+
+func IsARN(s string) bool {
+	return strings.HasPrefix(s, "arn:")
+}
+
+func ParseARN(s string) (ARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ARN{}, fmt.Errorf("invalid ARN %q - expected arn:partition:service:region:account-id:resource", s)
+	}
+
+	arn := ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+		Resource:  parts[5],
+	}
+
+	if arn.Partition == "" || arn.Service == "" || arn.Resource == "" {
+		return ARN{}, fmt.Errorf("invalid ARN %q - partition, service and resource are required", s)
+	}
+
+	return arn, nil
+}
+
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, a.Resource}, ":")
+}
+
+// ParameterName returns the parameter name of an SSM parameter ARN.
+// The leading "/" of hierarchical names is not part of the ARN, so it is restored when the name has a "/".
+func (a ARN) ParameterName() (string, error) {
+	name, ok := strings.CutPrefix(a.Resource, "parameter/")
+
+	if a.Service != "ssm" || !ok || name == "" {
+		return "", fmt.Errorf("not a parameter ARN: %s", a)
+	}
+
+	if strings.Contains(name, "/") {
+		name = "/" + name
+	}
+
+	return name, nil
+}
+
+// A full secret ARN ends with "-" and six random characters, a partial ARN does not.
+var secretSuffixPattern = regexp.MustCompile(`-[0-9A-Za-z]{6}$`)
+
+// IsPartialSecretARN is a guess: a partial ARN of a name such as "app-config" looks like a full ARN.
+func (a ARN) IsPartialSecretARN() bool {
+	return !secretSuffixPattern.MatchString(a.Resource)
+}
+
+// SecretName returns the secret name of a Secrets Manager ARN without the random suffix.
+// Names that end with "-" and six characters cannot be told apart from full ARNs, so they must be passed as full ARNs.
+func (a ARN) SecretName() (string, error) {
+	name, ok := strings.CutPrefix(a.Resource, "secret:")
+
+	if a.Service != "secretsmanager" || !ok || name == "" {
+		return "", fmt.Errorf("not a secret ARN: %s", a)
+	}
+
+	if !a.IsPartialSecretARN() {
+		name = secretSuffixPattern.ReplaceAllString(name, "")
+	}
+
+	return name, nil
+}
+
+func (o *ParameterOutputParameter) ParseARN() (ARN, error) {
+	return ParseARN(o.Arn)
+}
+
+func (o *SecretOutput) ParseARN() (ARN, error) {
+	return ParseARN(o.Arn)
+}
+
+// CanonicalParameterName returns a name as is, and an ARN in one form
+// (e.g. "arn:aws:ssm:us-west-2:123456789012:parameter/app/host").
+// The partition, region and account are kept, so parameters of other accounts or regions are never merged.
+func CanonicalParameterName(nameOrARN string) string {
+	if !IsARN(nameOrARN) {
+		return nameOrARN
+	}
+
+	arn, err := ParseARN(nameOrARN)
+
+	if err != nil {
+		return nameOrARN
+	}
+
+	name, err := arn.ParameterName()
+
+	if err != nil {
+		return nameOrARN
+	}
+
+	return accountOf(arn).arn(ServiceParameters, name)
+}
+
+// account is where names without an ARN are looked up.
+// It is comparable, so ARNs can be checked against the caller's account.
+type account struct {
+	partition string
+	region    string
+	accountID string
+}
+
+func accountOf(arn ARN) *account {
+	return &account{partition: arn.Partition, region: arn.Region, accountID: arn.AccountID}
+}
+
+func (a *account) arn(service string, name string) string {
+	if service == ServiceSecrets {
+		return ARN{Partition: a.partition, Service: "secretsmanager", Region: a.region, AccountID: a.accountID, Resource: "secret:" + name}.String()
+	}
+
+	return ARN{Partition: a.partition, Service: "ssm", Region: a.region, AccountID: a.accountID, Resource: "parameter/" + strings.TrimPrefix(name, "/")}.String()
+}
+
+// outputAccount returns the account in the ARN of an extension response.
+func outputAccount(service string, body []byte) (*account, bool) {
+	var arn string
+
+	if service == ServiceSecrets {
+		output := &SecretOutput{}
+
+		if json.Unmarshal(body, output) != nil {
+			return nil, false
+		}
+
+		arn = output.Arn
+	} else {
+		output := &ParameterOutput{}
+
+		if json.Unmarshal(body, output) != nil {
+			return nil, false
+		}
+
+		arn = output.Parameter.Arn
+	}
+
+	parsed, err := ParseARN(arn)
+
+	if err != nil || parsed.AccountID == "" {
+		return nil, false
+	}
+
+	return accountOf(parsed), true
+}
@@ -0,0 +1,193 @@
+package secretlamb_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestParseARN(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	arn, err := secretlamb.ParseARN("arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db-a1b2c3")
+	require.NoError(err)
+	assert.Equal(secretlamb.ARN{Partition: "aws", Service: "secretsmanager", Region: "us-west-2", AccountID: "123456789012", Resource: "secret:prod/db-a1b2c3"}, arn)
+	assert.Equal("arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db-a1b2c3", arn.String())
+	assert.False(arn.IsPartialSecretARN())
+
+	name, err := arn.SecretName()
+	require.NoError(err)
+	assert.Equal("prod/db", name)
+
+	_, err = arn.ParameterName()
+	assert.ErrorContains(err, "not a parameter ARN")
+
+	_, err = secretlamb.ParseARN("prod/db")
+	assert.ErrorContains(err, `invalid ARN "prod/db"`)
+	_, err = secretlamb.ParseARN("arn:aws::::")
+	assert.ErrorContains(err, "partition, service and resource are required")
+}
+
+func TestARNSecretName(t *testing.T) {
+	assert := assert.New(t)
+
+	for arn, expected := range map[string]string{
+		"arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3": "MyTestSecret",
+		"arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret":        "MyTestSecret",
+		"arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db":             "prod/db",
+	} {
+		parsed, err := secretlamb.ParseARN(arn)
+		assert.NoError(err)
+		name, err := parsed.SecretName()
+		assert.NoError(err)
+		assert.Equal(expected, name, arn)
+	}
+}
+
+func TestARNParameterName(t *testing.T) {
+	assert := assert.New(t)
+
+	for arn, expected := range map[string]string{
+		"arn:aws:ssm:us-east-2:111222333444:parameter/MyStringParameter": "MyStringParameter",
+		"arn:aws:ssm:us-east-2:111222333444:parameter/app/db/host":       "/app/db/host",
+	} {
+		parsed, err := secretlamb.ParseARN(arn)
+		assert.NoError(err)
+		name, err := parsed.ParameterName()
+		assert.NoError(err)
+		assert.Equal(expected, name, arn)
+	}
+}
+
+func TestCanonicalParameterName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("arn:aws:ssm:us-west-2:111222333444:parameter/app/host", secretlamb.CanonicalParameterName("arn:aws:ssm:us-west-2:111222333444:parameter/app/host"))
+	assert.Equal("/app/host", secretlamb.CanonicalParameterName("/app/host"))
+	assert.Equal("arn:broken", secretlamb.CanonicalParameterName("arn:broken"))
+}
+
+func TestOutputParseARN(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	arn, err := (&secretlamb.SecretOutput{Arn: "arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3"}).ParseARN()
+	require.NoError(err)
+	assert.Equal("123456789012", arn.AccountID)
+
+	arn, err = (&secretlamb.ParameterOutputParameter{Arn: "arn:aws:ssm:us-east-2:111222333444:parameter/MyStringParameter"}).ParseARN()
+	require.NoError(err)
+	assert.Equal("us-east-2", arn.Region)
+}
+
+func TestCacheByNameAndARN(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=MyTestSecret", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "Vidi")))
+
+	s := secretlamb.MustNewSecrets().WithCache(secretlamb.NewCache(0))
+	_, err := s.Get("MyTestSecret")
+	require.NoError(err)
+
+	// the response tells the caller's account, so the ARNs of the secret in that account share the entry
+	value, err := s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3")
+	require.NoError(err)
+	assert.Equal("Vidi", value.SecretString)
+	_, err = s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret")
+	require.NoError(err)
+	_, err = s.Get("MyTestSecret")
+	require.NoError(err)
+	assert.Equal(1, httpmock.GetTotalCallCount())
+}
+
+func TestCacheByARNOfAnotherAccount(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mine := `{"ARN":"arn:aws:secretsmanager:us-west-2:222222222222:secret:prod/db-a1b2c3","Name":"prod/db","VersionId":"v1","SecretString":"MINE"}`
+	theirs := `{"ARN":"arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef","Name":"prod/db","VersionId":"v1","SecretString":"THEIRS"}`
+	other := `{"ARN":"arn:aws:secretsmanager:eu-west-1:222222222222:secret:prod/db-ghijkl","Name":"prod/db","VersionId":"v1","SecretString":"EU"}`
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, mine))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A111111111111%3Asecret%3Aprod%2Fdb-abcdef", httpmock.NewStringResponder(http.StatusOK, theirs))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aeu-west-1%3A222222222222%3Asecret%3Aprod%2Fdb", httpmock.NewStringResponder(http.StatusOK, other))
+
+	s := secretlamb.MustNewSecrets().WithCache(secretlamb.NewCache(0))
+
+	value, err := s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("MINE", value.SecretString)
+
+	value, err = s.Get("arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef")
+	require.NoError(err)
+	assert.Equal("THEIRS", value.SecretString)
+
+	value, err = s.Get("arn:aws:secretsmanager:eu-west-1:222222222222:secret:prod/db")
+	require.NoError(err)
+	assert.Equal("EU", value.SecretString)
+
+	value, err = s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("MINE", value.SecretString)
+	assert.Equal(3, httpmock.GetTotalCallCount())
+}
+
+func TestCacheByARNBeforeName(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	theirs := `{"ARN":"arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef","Name":"prod/db","VersionId":"v1","SecretString":"THEIRS"}`
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A111111111111%3Asecret%3Aprod%2Fdb-abcdef", httpmock.NewStringResponder(http.StatusOK, theirs))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "MINE")))
+
+	// the caller's account is unknown, so an ARN never answers a request by name
+	s := secretlamb.MustNewSecrets().WithCache(secretlamb.NewCache(0))
+	_, err := s.Get("arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef")
+	require.NoError(err)
+
+	value, err := s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("MINE", value.SecretString)
+}
+
+func TestCacheByPartialARNsWithSuffixLikeNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	config := `{"ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config-Ab12Cd","Name":"app-config","VersionId":"v1","SecretString":"CONFIG"}`
+	backup := `{"ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:app-backup-Ef34Gh","Name":"app-backup","VersionId":"v1","SecretString":"BACKUP"}`
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A123456789012%3Asecret%3Aapp-config", httpmock.NewStringResponder(http.StatusOK, config))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A123456789012%3Asecret%3Aapp-backup", httpmock.NewStringResponder(http.StatusOK, backup))
+
+	// "-config" and "-backup" look like random suffixes, but the responses show they are part of the names.
+	s := secretlamb.MustNewSecrets().WithCache(secretlamb.NewCache(0))
+
+	value, err := s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config")
+	require.NoError(err)
+	assert.Equal("CONFIG", value.SecretString)
+
+	value, err = s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:app-backup")
+	require.NoError(err)
+	assert.Equal("BACKUP", value.SecretString)
+
+	// The full ARN confirmed by the response shares the entry of the partial ARN.
+	value, err = s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config-Ab12Cd")
+	require.NoError(err)
+	assert.Equal("CONFIG", value.SecretString)
+	assert.Equal(2, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,195 @@
+package secretlamb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeError   = "error"
+)
+
+type AuditRecord struct {
+	Service string `json:"service"`
+	Name    string `json:"name"`
+	// Version is the requested version, label or stage.
+	Version string `json:"version,omitempty"`
+	// VersionID is the version that was read: the parameter version or the secret VersionId.
+	VersionID string    `json:"versionId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Outcome   string    `json:"outcome"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type AuditSink interface {
+	WriteAudit(ctx context.Context, records []AuditRecord) error
+}
+
+// WriterAuditSink writes each flush as one JSON line: {"records":[...]}.
+type WriterAuditSink struct {
+	Writer io.Writer
+	mu     sync.Mutex
+}
+
+func NewStdoutAuditSink() *WriterAuditSink {
+	return &WriterAuditSink{Writer: os.Stdout}
+}
+
+// NewFileAuditSink appends to path, creating it with mode 0600.
+func NewFileAuditSink(path string) (*WriterAuditSink, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &WriterAuditSink{Writer: f}, f, nil
+}
+
+func (sink *WriterAuditSink) WriteAudit(ctx context.Context, records []AuditRecord) error {
+	line, err := json.Marshal(map[string]any{"records": records})
+
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	_, err = sink.Writer.Write(append(line, '\n'))
+
+	return err
+}
+
+type ChannelAuditSink chan<- []AuditRecord
+
+func (sink ChannelAuditSink) WriteAudit(ctx context.Context, records []AuditRecord) error {
+	select {
+	case sink <- records:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type auditScopeKey struct{}
+
+type auditScope struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (scope *auditScope) add(record AuditRecord) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.records = append(scope.records, record)
+}
+
+func (scope *auditScope) take() []AuditRecord {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	records := scope.records
+	scope.records = nil
+	return records
+}
+
+// AuditRecorder collects the parameters and secrets read per scope.
+// Call Scope at the start of each invocation and Flush at the end;
+// lookups with a context outside any scope are collected in a shared scope.
+type AuditRecorder struct {
+	Sink   AuditSink
+	shared auditScope
+}
+
+func NewAuditRecorder(sink AuditSink) *AuditRecorder {
+	return &AuditRecorder{Sink: sink}
+}
+
+func (r *AuditRecorder) Scope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditScopeKey{}, &auditScope{})
+}
+
+func (r *AuditRecorder) scope(ctx context.Context) *auditScope {
+	if scope, ok := ctx.Value(auditScopeKey{}).(*auditScope); ok {
+		return scope
+	}
+
+	return &r.shared
+}
+
+func (r *AuditRecorder) Records(ctx context.Context) []AuditRecord {
+	scope := r.scope(ctx)
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	return append([]AuditRecord{}, scope.records...)
+}
+
+// Flush writes the records of the scope of ctx to the sink and clears them.
+func (r *AuditRecorder) Flush(ctx context.Context) error {
+	records := r.scope(ctx).take()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	return r.Sink.WriteAudit(ctx, records)
+}
+
+func (r *AuditRecorder) record(ctx context.Context, record AuditRecord, err error) {
+	record.Timestamp = time.Now()
+	record.Outcome = AuditOutcomeSuccess
+
+	if err != nil {
+		record.Outcome = AuditOutcomeError
+		record.Error = err.Error()
+	}
+
+	r.scope(ctx).add(record)
+}
+
+func (p *Parameters) auditParameter(ctx context.Context, query *url.Values, output *ParameterOutput, err error) {
+	record := AuditRecord{
+		Service: p.service,
+		Name:    query.Get("name"),
+		Version: joinNonEmpty(query.Get("version"), query.Get("label")),
+	}
+
+	if output != nil {
+		record.VersionID = strconv.FormatInt(output.Parameter.Version, 10)
+	}
+
+	p.auditor.record(ctx, record, err)
+}
+
+func (s *Secrets) auditSecret(ctx context.Context, query *url.Values, output *SecretOutput, err error) {
+	record := AuditRecord{
+		Service: s.service,
+		Name:    query.Get("secretId"),
+		Version: joinNonEmpty(query.Get("versionId"), query.Get("versionStage")),
+	}
+
+	if output != nil {
+		record.VersionID = output.VersionID
+	}
+
+	s.auditor.record(ctx, record, err)
+}
+
+func joinNonEmpty(values ...string) string {
+	nonEmpty := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+
+	return strings.Join(nonEmpty, ",")
+}
@@ -0,0 +1,125 @@
+package secretlamb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestAuditRecorder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?label=prod&name=foo", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 3, "Veni")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo&versionStage=AWSCURRENT", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "a1b2c3d4", "Vidi")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bar", httpmock.NewStringResponder(http.StatusBadRequest, "ResourceNotFoundException"))
+
+	var buf bytes.Buffer
+	recorder := secretlamb.NewAuditRecorder(&secretlamb.WriterAuditSink{Writer: &buf})
+	p := secretlamb.MustNewParameters().WithAuditRecorder(recorder)
+	s := secretlamb.MustNewSecrets().WithAuditRecorder(recorder)
+
+	ctx := recorder.Scope(context.Background())
+	_, err := p.GetWithContext(ctx, "foo", secretlamb.ParameterLabel("prod"))
+	require.NoError(err)
+	_, err = s.GetWithContext(ctx, "foo", []*secretlamb.SecretOption{secretlamb.SecretVersionStage("AWSCURRENT")})
+	require.NoError(err)
+	_, err = s.GetWithContext(ctx, "bar", nil)
+	require.Error(err)
+
+	records := recorder.Records(ctx)
+	require.Len(records, 3)
+
+	for i := range records {
+		assert.False(records[i].Timestamp.IsZero())
+		records[i].Timestamp = records[0].Timestamp
+	}
+
+	require.NoError(recorder.Flush(ctx))
+	assert.Empty(recorder.Records(ctx))
+
+	var flushed struct {
+		Records []secretlamb.AuditRecord `json:"records"`
+	}
+
+	require.NoError(json.Unmarshal(buf.Bytes(), &flushed))
+	assert.Len(flushed.Records, 3)
+	assert.NotContains(buf.String(), "Veni")
+	assert.NotContains(buf.String(), "Vidi")
+
+	ts := records[0].Timestamp
+	assert.Equal([]secretlamb.AuditRecord{
+		{Service: "ssm", Name: "foo", Version: "prod", VersionID: "3", Timestamp: ts, Outcome: "success"},
+		{Service: "secretsmanager", Name: "foo", Version: "AWSCURRENT", VersionID: "a1b2c3d4", Timestamp: ts, Outcome: "success"},
+		{Service: "secretsmanager", Name: "bar", Timestamp: ts, Outcome: "error", Error: "failed to get secret - http request error: 400 Bad Request: ResourceNotFoundException"},
+	}, records)
+}
+
+func TestAuditRecorderConcurrentScopes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ch := make(chan []secretlamb.AuditRecord, 10)
+	recorder := secretlamb.NewAuditRecorder(secretlamb.ChannelAuditSink(ch))
+	p := secretlamb.MustNewParameters().WithAuditRecorder(recorder)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=a", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "1")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=b", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 2, "2")))
+
+	var wg sync.WaitGroup
+
+	for _, name := range []string{"a", "b"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			ctx := recorder.Scope(context.Background())
+			_, err := p.GetWithContext(ctx, name)
+			assert.NoError(err)
+			assert.NoError(recorder.Flush(ctx))
+		}()
+	}
+
+	wg.Wait()
+	close(ch)
+	names := []string{}
+
+	for records := range ch {
+		require.Len(records, 1)
+		names = append(names, records[0].Name)
+	}
+
+	assert.ElementsMatch([]string{"a", "b"}, names)
+}
+
+func TestFileAuditSink(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, closer, err := secretlamb.NewFileAuditSink(path)
+	require.NoError(err)
+	require.NoError(sink.WriteAudit(context.Background(), []secretlamb.AuditRecord{{Service: "ssm", Name: "foo", Outcome: "success"}}))
+	require.NoError(closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Contains(string(data), `{"records":[{"service":"ssm","name":"foo","timestamp":"0001-01-01T00:00:00Z","outcome":"success"}]}`)
+
+	info, err := os.Stat(path)
+	require.NoError(err)
+	assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+}
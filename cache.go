@@ -0,0 +1,63 @@
+package secretlamb
+
+import (
+	"sync"
+	"time"
+)
+
+var DefaultCache = NewCache(5 * time.Minute)
+
+type Cache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewCache returns an in-memory cache of extension responses. A ttl <= 0 never expires entries.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func (c *Cache) get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+
+	if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		return nil, false
+	}
+
+	return entry.body, true
+}
+
+func (c *Cache) set(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := cacheEntry{body: body}
+
+	if c.ttl > 0 {
+		entry.expiresAt = time.Now().Add(c.ttl)
+	}
+
+	c.entries[key] = entry
+}
+
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cacheEntry{}
+}
@@ -0,0 +1,59 @@
+package secretlamb_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestParametersWithCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "Veni")))
+
+	cache := secretlamb.NewCache(0)
+	p := secretlamb.MustNewParameters().WithCache(cache)
+
+	for range 3 {
+		value, err := p.Get("foo")
+		require.NoError(err)
+		assert.Equal("Veni", value.Parameter.Value)
+	}
+
+	assert.Equal(1, httpmock.GetTotalCallCount())
+	assert.Equal(1, cache.Len())
+
+	_, err := p.Get("foo", secretlamb.ParameterVersion(1))
+	assert.Error(err)
+	assert.Equal(1, cache.Len())
+}
+
+func TestSecretsWithCacheExpire(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "bar")))
+
+	cache := secretlamb.NewCache(time.Millisecond)
+	s := secretlamb.MustNewSecrets().WithCache(cache)
+
+	_, err := s.Get("foo")
+	require.NoError(err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = s.Get("foo")
+	require.NoError(err)
+	assert.Equal(2, httpmock.GetTotalCallCount())
+
+	cache.Clear()
+	assert.Equal(0, cache.Len())
+}
@@ -0,0 +1,156 @@
+package secretlamb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+type FallthroughPolicy func(err error) bool
+
+func FallthroughOnNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// FallthroughOnTransportError matches failures to reach a source, e.g. no extension listening outside Lambda.
+func FallthroughOnTransportError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, ErrDeadlineBudgetExceeded)
+}
+
+func FallthroughOnAny(err error) bool {
+	return true
+}
+
+func FallthroughOn(policies ...FallthroughPolicy) FallthroughPolicy {
+	return func(err error) bool {
+		for _, policy := range policies {
+			if policy(err) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+var DefaultFallthroughPolicy = FallthroughOn(FallthroughOnNotFound, FallthroughOnTransportError)
+
+var ErrNoSources = errors.New("no sources")
+
+func policyOrDefault(policy FallthroughPolicy) FallthroughPolicy {
+	if policy == nil {
+		return DefaultFallthroughPolicy
+	}
+
+	return policy
+}
+
+type ParameterSource struct {
+	Name   string
+	Getter ParameterGetter
+}
+
+// ParameterChain tries Sources in order and moves on to the next source when Policy matches the error.
+// A nil Policy means DefaultFallthroughPolicy.
+type ParameterChain struct {
+	Sources []ParameterSource
+	Policy  FallthroughPolicy
+}
+
+func NewParameterChain(sources ...ParameterSource) *ParameterChain {
+	return &ParameterChain{
+		Sources: sources,
+		Policy:  DefaultFallthroughPolicy,
+	}
+}
+
+func (c *ParameterChain) Get(name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return c.GetWithContext(context.Background(), name, options...)
+}
+
+func (c *ParameterChain) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	output, _, err := c.GetWithSource(ctx, name, options...)
+	return output, err
+}
+
+// GetWithSource also returns the name of the source that answered.
+func (c *ParameterChain) GetWithSource(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, string, error) {
+	if len(c.Sources) == 0 {
+		return nil, "", fmt.Errorf("failed to get parameter from chain - %w", ErrNoSources)
+	}
+
+	policy := policyOrDefault(c.Policy)
+	errs := []error{}
+
+	for _, src := range c.Sources {
+		output, err := src.Getter.GetWithContext(ctx, name, options...)
+
+		if err == nil {
+			return output, src.Name, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+
+		if !policy(err) {
+			break
+		}
+	}
+
+	return nil, "", fmt.Errorf("failed to get parameter from chain - %w", errors.Join(errs...))
+}
+
+type SecretSource struct {
+	Name   string
+	Getter SecretGetter
+}
+
+// SecretChain tries Sources in order and moves on to the next source when Policy matches the error.
+// A nil Policy means DefaultFallthroughPolicy.
+type SecretChain struct {
+	Sources []SecretSource
+	Policy  FallthroughPolicy
+}
+
+func NewSecretChain(sources ...SecretSource) *SecretChain {
+	return &SecretChain{
+		Sources: sources,
+		Policy:  DefaultFallthroughPolicy,
+	}
+}
+
+func (c *SecretChain) Get(secretId string, options ...*SecretOption) (*SecretOutput, error) {
+	return c.GetWithContext(context.Background(), secretId, options)
+}
+
+func (c *SecretChain) GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error) {
+	output, _, err := c.GetWithSource(ctx, secretId, options...)
+	return output, err
+}
+
+// GetWithSource also returns the name of the source that answered.
+func (c *SecretChain) GetWithSource(ctx context.Context, secretId string, options ...*SecretOption) (*SecretOutput, string, error) {
+	if len(c.Sources) == 0 {
+		return nil, "", fmt.Errorf("failed to get secret from chain - %w", ErrNoSources)
+	}
+
+	policy := policyOrDefault(c.Policy)
+	errs := []error{}
+
+	for _, src := range c.Sources {
+		output, err := src.Getter.GetWithContext(ctx, secretId, options)
+
+		if err == nil {
+			return output, src.Name, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+
+		if !policy(err) {
+			break
+		}
+	}
+
+	return nil, "", fmt.Errorf("failed to get secret from chain - %w", errors.Join(errs...))
+}
@@ -0,0 +1,119 @@
+package secretlamb_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestParameterChain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fhost", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 2, "ssm.example.com")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fport", httpmock.NewStringResponder(http.StatusBadRequest, "ParameterNotFound"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fuser", httpmock.NewStringResponder(http.StatusForbidden, "AccessDeniedException"))
+
+	chain := secretlamb.NewParameterChain(
+		secretlamb.ParameterSource{Name: "env", Getter: secretlamb.NewLocalParameters(secretlamb.MapSource{"/app/debug": "true"})},
+		secretlamb.ParameterSource{Name: "extension", Getter: secretlamb.MustNewParameters()},
+		secretlamb.ParameterSource{Name: "default", Getter: secretlamb.NewLocalParameters(secretlamb.MapSource{"/app/port": "5432", "/app/user": "app", "/app/name": "db"})},
+	)
+
+	for _, tt := range []struct {
+		name   string
+		value  string
+		source string
+	}{
+		{name: "/app/debug", value: "true", source: "env"},
+		{name: "/app/host", value: "ssm.example.com", source: "extension"},
+		{name: "/app/port", value: "5432", source: "default"},
+		// no responder: transport error
+		{name: "/app/name", value: "db", source: "default"},
+	} {
+		value, source, err := chain.GetWithSource(context.Background(), tt.name)
+		require.NoError(err)
+		assert.Equal(tt.value, value.Parameter.Value)
+		assert.Equal(tt.source, source)
+	}
+
+	_, err := chain.Get("/app/user")
+	var httpErr *secretlamb.HTTPError
+	require.ErrorAs(err, &httpErr)
+	assert.Equal(http.StatusForbidden, httpErr.StatusCode)
+	assert.ErrorContains(err, "failed to get parameter from chain - env: failed to get parameter - not found: /app/user\nextension: failed to get parameter - http request error: 403 Forbidden: AccessDeniedException")
+
+	chain.Policy = secretlamb.FallthroughOnAny
+	value, err := chain.Get("/app/user")
+	require.NoError(err)
+	assert.Equal("app", value.Parameter.Value)
+
+	chain.Policy = secretlamb.FallthroughOnNotFound
+	_, err = chain.Get("/app/name")
+	assert.ErrorContains(err, "no responder found")
+}
+
+func TestSecretChain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fallback := secretlamb.NewFakeSecrets(map[string]string{"prod/db": "default"})
+	chain := secretlamb.NewSecretChain(
+		secretlamb.SecretSource{Name: "primary", Getter: secretlamb.NewFakeSecrets(map[string]string{"prod/api": "key"})},
+		secretlamb.SecretSource{Name: "fallback", Getter: fallback},
+	)
+
+	value, source, err := chain.GetWithSource(context.Background(), "prod/db")
+	require.NoError(err)
+	assert.Equal("default", value.SecretString)
+	assert.Equal("fallback", source)
+
+	value, err = chain.Get("prod/api")
+	require.NoError(err)
+	assert.Equal("key", value.SecretString)
+
+	fallback.Errors["prod/db"] = errors.New("boom")
+	_, err = chain.GetWithContext(context.Background(), "prod/db", nil)
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.ErrorContains(err, "fallback: boom")
+}
+
+func TestChainZeroValue(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// a nil Policy falls through on not found
+	parameters := &secretlamb.ParameterChain{Sources: []secretlamb.ParameterSource{
+		{Name: "primary", Getter: secretlamb.NewFakeParameters(nil)},
+		{Name: "fallback", Getter: secretlamb.NewFakeParameters(map[string]string{"/app/host": "db.example.com"})},
+	}}
+
+	value, source, err := parameters.GetWithSource(context.Background(), "/app/host")
+	require.NoError(err)
+	assert.Equal("db.example.com", value.Parameter.Value)
+	assert.Equal("fallback", source)
+
+	secrets := &secretlamb.SecretChain{Sources: []secretlamb.SecretSource{
+		{Name: "primary", Getter: secretlamb.NewFakeSecrets(nil)},
+		{Name: "fallback", Getter: secretlamb.NewFakeSecrets(map[string]string{"prod/db": "tiger"})},
+	}}
+
+	secret, err := secrets.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("tiger", secret.SecretString)
+
+	_, err = (&secretlamb.ParameterChain{}).Get("/app/host")
+	assert.ErrorIs(err, secretlamb.ErrNoSources)
+	assert.EqualError(err, "failed to get parameter from chain - no sources")
+
+	_, err = (&secretlamb.SecretChain{}).Get("prod/db")
+	assert.EqualError(err, "failed to get secret from chain - no sources")
+}
@@ -0,0 +1,207 @@
+package secretlamb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ChunkManifestName is the optional manifest parameter under the chunk prefix, e.g. /app/big/manifest.
+const ChunkManifestName = "manifest"
+
+// ChunkDiscoveryBatch is the number of chunks fetched concurrently while looking for the last chunk
+// when there is no manifest.
+var ChunkDiscoveryBatch = 8
+
+var (
+	ErrChunkVersionMismatch = errors.New("chunk version mismatch")
+	ErrChunkHashMismatch    = errors.New("chunk hash mismatch")
+)
+
+// ChunkManifest is stored as JSON, e.g. {"count":3,"sha256":"...","versions":[4,4,5]}.
+// When Versions is given, each chunk is fetched at that version.
+type ChunkManifest struct {
+	Count    int     `json:"count"`
+	SHA256   string  `json:"sha256,omitempty"`
+	Versions []int64 `json:"versions,omitempty"`
+}
+
+type ChunkedOutput struct {
+	Value    string
+	Chunks   []*ParameterOutput
+	Manifest *ChunkManifest
+}
+
+// GetChunked reassembles a value split into prefix/0, prefix/1, ...
+// Without a manifest, chunks are read up to the first missing one and are not checked for consistency,
+// since each parameter has its own version counter. Use a manifest with versions and sha256 for that.
+// The manifest hash is checked before options such as ParameterDecode are applied.
+// The decrypter set by WithDecrypter applies to the reassembled value, not to the manifest or each chunk.
+func (p *Parameters) GetChunked(ctx context.Context, prefix string, options ...*ParameterOption) (*ChunkedOutput, error) {
+	if d := p.clientDecrypter(ctx); d != nil {
+		options = append([]*ParameterOption{ParameterDecrypt(d)}, options...)
+	}
+
+	return getChunked(ctx, undecryptedParameters{p}, prefix, options)
+}
+
+func getChunked(ctx context.Context, getter ParameterGetter, prefix string, options []*ParameterOption) (*ChunkedOutput, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	options, transforms := splitParameterOptions(options)
+	manifest, err := getChunkManifest(ctx, getter, prefix, options)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunked parameter - manifest error: %w", err)
+	}
+
+	var chunks []*ParameterOutput
+
+	if manifest != nil {
+		chunks, err = getChunks(ctx, getter, prefix, options, manifest)
+	} else {
+		chunks, err = discoverChunks(ctx, getter, prefix, options)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunked parameter - %w", err)
+	}
+
+	if manifest != nil {
+		err = checkChunkVersions(chunks, manifest)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunked parameter - %w", err)
+		}
+	}
+
+	var value strings.Builder
+
+	for _, chunk := range chunks {
+		value.WriteString(chunk.Parameter.Value)
+	}
+
+	if manifest != nil && manifest.SHA256 != "" {
+		sum := sha256.Sum256([]byte(value.String()))
+
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), manifest.SHA256) {
+			return nil, fmt.Errorf("failed to get chunked parameter - %w: %s", ErrChunkHashMismatch, prefix)
+		}
+	}
+
+	// Options such as ParameterDecode apply to the reassembled value, not to each chunk.
+	reassembled := &ParameterOutput{Parameter: chunks[0].Parameter}
+	reassembled.Parameter.Value = value.String()
+	err = applyParameterTransforms(ctx, reassembled, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunked parameter - %w", err)
+	}
+
+	output := &ChunkedOutput{
+		Value:    reassembled.Parameter.Value,
+		Chunks:   chunks,
+		Manifest: manifest,
+	}
+
+	return output, nil
+}
+
+func chunkName(prefix string, i int) string {
+	return prefix + "/" + strconv.Itoa(i)
+}
+
+func getChunkManifest(ctx context.Context, getter ParameterGetter, prefix string, options []*ParameterOption) (*ChunkManifest, error) {
+	output, err := getter.GetWithContext(ctx, prefix+"/"+ChunkManifestName, options...)
+
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest := &ChunkManifest{}
+	err = json.Unmarshal([]byte(output.Parameter.Value), manifest)
+
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+
+	if manifest.Count <= 0 {
+		return nil, fmt.Errorf("invalid count: %d", manifest.Count)
+	} else if len(manifest.Versions) > 0 && len(manifest.Versions) != manifest.Count {
+		return nil, fmt.Errorf("%d versions for %d chunks", len(manifest.Versions), manifest.Count)
+	}
+
+	return manifest, nil
+}
+
+func getChunks(ctx context.Context, getter ParameterGetter, prefix string, options []*ParameterOption, manifest *ChunkManifest) ([]*ParameterOutput, error) {
+	chunks := make([]*ParameterOutput, manifest.Count)
+	errs := make([]error, manifest.Count)
+	var wg sync.WaitGroup
+
+	for i := range chunks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			opts := options
+
+			if len(manifest.Versions) > 0 {
+				opts = append(opts[:len(opts):len(opts)], ParameterVersion(int(manifest.Versions[i])))
+			}
+
+			chunks[i], errs[i] = getter.GetWithContext(ctx, chunkName(prefix, i), opts...)
+		}()
+	}
+
+	wg.Wait()
+	return chunks, errors.Join(errs...)
+}
+
+func discoverChunks(ctx context.Context, getter ParameterGetter, prefix string, options []*ParameterOption) ([]*ParameterOutput, error) {
+	chunks := []*ParameterOutput{}
+
+	for {
+		batch := make([]*ParameterOutput, ChunkDiscoveryBatch)
+		errs := make([]error, ChunkDiscoveryBatch)
+		var wg sync.WaitGroup
+
+		for i := range batch {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				batch[i], errs[i] = getter.GetWithContext(ctx, chunkName(prefix, len(chunks)+i), options...)
+			}()
+		}
+
+		wg.Wait()
+
+		for i, err := range errs {
+			if errors.Is(err, ErrNotFound) && len(chunks)+i > 0 {
+				return chunks, nil
+			} else if err != nil {
+				return nil, err
+			}
+
+			chunks = append(chunks, batch[i])
+		}
+	}
+}
+
+func checkChunkVersions(chunks []*ParameterOutput, manifest *ChunkManifest) error {
+	for i, expected := range manifest.Versions {
+		if chunks[i].Parameter.Version != expected {
+			return fmt.Errorf("%w: %s has version %d, expected %d", ErrChunkVersionMismatch, chunks[i].Parameter.Name, chunks[i].Parameter.Version, expected)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package secretlamb_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func registerChunks(t *testing.T, prefix string, versions []int64, values []string) {
+	for i, value := range values {
+		url := fmt.Sprintf("http://localhost:2773/systemsmanager/parameters/get/?name=%s", url.QueryEscape(fmt.Sprintf("%s/%d", prefix, i)))
+		httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, versions[i], value)))
+	}
+
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusBadRequest, "ParameterNotFound"))
+}
+
+func TestParametersGetChunked(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	values := []string{}
+	versions := []int64{}
+
+	for i := range 10 {
+		values = append(values, fmt.Sprintf("chunk%d,", i))
+		versions = append(versions, 3)
+	}
+
+	registerChunks(t, "/app/big", versions, values)
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.GetChunked(context.Background(), "/app/big/")
+	require.NoError(err)
+	assert.Equal("chunk0,chunk1,chunk2,chunk3,chunk4,chunk5,chunk6,chunk7,chunk8,chunk9,", output.Value)
+	assert.Len(output.Chunks, 10)
+	assert.Nil(output.Manifest)
+}
+
+func TestParametersGetChunkedVersionMismatch(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2Fmanifest", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, `{"count":2,"versions":[3,3]}`)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2F0&version=3", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 3, "a")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2F1&version=3", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 4, "b")))
+
+	p := secretlamb.MustNewParameters()
+	_, err := p.GetChunked(context.Background(), "/app/big")
+	assert.ErrorIs(err, secretlamb.ErrChunkVersionMismatch)
+}
+
+func TestParametersGetChunkedWithoutManifest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// The value grew from 2 to 3 chunks, so the new chunk has its own version.
+	registerChunks(t, "/app/big", []int64{2, 2, 1}, []string{"a", "b", "c"})
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.GetChunked(context.Background(), "/app/big")
+	require.NoError(err)
+	assert.Equal("abc", output.Value)
+}
+
+func TestParametersGetChunkedNotFound(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerChunks(t, "/app/big", nil, nil)
+
+	p := secretlamb.MustNewParameters()
+	_, err := p.GetChunked(context.Background(), "/app/big")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+}
+
+func TestParametersGetChunkedWithManifest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	sum := sha256.Sum256([]byte("abc"))
+	manifest := fmt.Sprintf(`{"count":3,"sha256":"%s","versions":[2,5,5]}`, hex.EncodeToString(sum[:]))
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2Fmanifest&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, manifest)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2F0&version=2&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 2, "a")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2F1&version=5&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 5, "b")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2F2&version=5&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 5, "c")))
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.GetChunked(context.Background(), "/app/big", secretlamb.ParameterWithDecryption())
+	require.NoError(err)
+	assert.Equal("abc", output.Value)
+	assert.Equal(3, output.Manifest.Count)
+	assert.Equal([]int64{2, 5, 5}, output.Manifest.Versions)
+}
+
+func TestParametersGetChunkedHashMismatch(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2Fmanifest", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, `{"count":2,"sha256":"00"}`)))
+	registerChunks(t, "/app/big", []int64{1, 1}, []string{"a", "b"})
+
+	p := secretlamb.MustNewParameters()
+	_, err := p.GetChunked(context.Background(), "/app/big")
+	assert.ErrorIs(err, secretlamb.ErrChunkHashMismatch)
+}
+
+func TestParametersGetChunkedWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12781")
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chunks := map[string]string{"/app/big/0": "a", "/app/big/1": "b"}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := chunks[r.URL.Query().Get("name")]
+
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "ParameterNotFound")
+			return
+		}
+
+		fmt.Fprint(w, parameterResponse(t, 1, value))
+	})
+
+	l, _ := net.Listen("tcp", ":12781")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	p := secretlamb.MustNewParameters().WithRetry(1)
+	output, err := p.GetChunked(context.Background(), "/app/big")
+	require.NoError(err)
+	assert.Equal("ab", output.Value)
+	assert.Nil(output.Manifest)
+
+	available, err := p.Public().ServiceAvailable(context.Background(), "lambda", "mars-east-1")
+	require.NoError(err)
+	assert.False(available)
+}
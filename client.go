@@ -1,20 +1,108 @@
 package secretlamb
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const notReadyMessage = "not ready to serve traffic"
+
+var (
+	DefaultWaitReadyTimeout = 10 * time.Second
+	waitReadyMinInterval    = 10 * time.Millisecond
+	waitReadyMaxInterval    = 500 * time.Millisecond
+)
+
+var ErrNotFound = errors.New("not found")
+
+var ErrAccessDenied = errors.New("access denied")
+
+var ErrDeadlineBudgetExceeded = errors.New("lambda deadline budget exceeded")
+
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	text := e.Status
+
+	if len(e.Body) > 0 {
+		text += ": " + e.Body
+	}
+
+	return text
+}
+
+// Is reports the extension's "ParameterNotFound"/"ResourceNotFoundException" errors as ErrNotFound
+// and "AccessDeniedException" errors as ErrAccessDenied.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || strings.Contains(e.Body, "NotFound")
+	case ErrAccessDenied:
+		return e.StatusCode == http.StatusForbidden || strings.Contains(e.Body, "AccessDenied")
+	}
+
+	return false
+}
+
+type WaitReadyTimeoutError struct {
+	Waited     time.Duration
+	LastStatus string
+	Err        error
+}
+
+func (e *WaitReadyTimeoutError) Error() string {
+	return fmt.Sprintf("extension not ready after %s (last status: %s): %s", e.Waited, e.LastStatus, e.Err)
+}
+
+func (e *WaitReadyTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	ServiceParameters = "ssm"
+	ServiceSecrets    = "secretsmanager"
 )
 
 type client struct {
-	url        *url.URL
-	HTTPClient *http.Client
+	url            *url.URL
+	HTTPClient     *http.Client
+	service        string
+	nameKey        string
+	cache          *Cache
+	retryClient    *retryablehttp.Client
+	safetyBudget   time.Duration
+	attemptTimeout time.Duration
+	observers      []Observer
+	otel           *otelObserver
+	logger         *slogObserver
+	auditor        *AuditRecorder
+	decrypter      Decrypter
+	lockfile       *Lockfile
+	// caller is learned from the first response to a request by name.
+	caller atomic.Pointer[account]
+	// secretNames maps the secret ARNs confirmed by responses, full and partial, to the secret name.
+	secretNames sync.Map
 }
 
-func newClient(path string) (*client, error) {
+func newClient(service string, nameKey string, path string) (*client, error) {
 	port := os.Getenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT")
 
 	if port == "" {
@@ -30,13 +118,167 @@ func newClient(path string) (*client, error) {
 	client := &client{
 		url:        url,
 		HTTPClient: http.DefaultClient,
+		service:    service,
+		nameKey:    nameKey,
 	}
 
 	return client, nil
 }
 
 func (client *client) get(ctx context.Context, query *url.Values) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url.String(), nil)
+	cacheKey := client.cacheKey(query)
+	ev := newEvent(client.service, client.nameKey, query)
+
+	if client.cache != nil {
+		if body, ok := client.cache.get(cacheKey); ok {
+			ev.CacheHit = true
+			client.notifyResponse(ctx, ev)
+			return body, nil
+		}
+	}
+
+	start := time.Now()
+	ctx, err := client.notifyRequest(ctx, ev)
+	var body []byte
+
+	if err == nil {
+		body, err = client.do(context.WithValue(ctx, eventKey{}, ev), ev, query)
+	}
+
+	ev.Duration = time.Since(start)
+
+	if err != nil {
+		ev.Err = err
+		client.notifyError(ctx, ev)
+		return nil, err
+	}
+
+	client.notifyResponse(ctx, ev)
+	client.learnCaller(query, body)
+	client.learnSecretName(body)
+
+	if client.cache != nil {
+		// The key changes once the response has confirmed the ARN.
+		client.cache.set(client.cacheKey(query), body)
+	}
+
+	return body, nil
+}
+
+// learnCaller records the account of a response to a request by name, which is always the caller's account.
+func (client *client) learnCaller(query *url.Values, body []byte) {
+	if IsARN(query.Get(client.nameKey)) || client.caller.Load() != nil {
+		return
+	}
+
+	if caller, ok := outputAccount(client.service, body); ok {
+		client.caller.CompareAndSwap(nil, caller)
+	}
+}
+
+// learnSecretName records the full ARN of a secret response, and the partial ARN
+// when the full ARN is the partial ARN plus the random suffix.
+func (client *client) learnSecretName(body []byte) {
+	if client.service != ServiceSecrets {
+		return
+	}
+
+	output := &SecretOutput{}
+
+	if json.Unmarshal(body, output) != nil || output.Name == "" {
+		return
+	}
+
+	full, err := ParseARN(output.Arn)
+
+	if err != nil {
+		return
+	}
+
+	client.secretNames.Store(full.String(), output.Name)
+	partial := accountOf(full).arn(ServiceSecrets, output.Name)
+	suffix, ok := strings.CutPrefix(full.String(), partial)
+
+	if ok && secretSuffixPattern.MatchString(suffix) && len(suffix) == 7 {
+		client.secretNames.Store(partial, output.Name)
+	}
+}
+
+// resourceName returns the name in an ARN.
+// A secret ARN may or may not end with the random suffix, so its name is known only from a response.
+func (client *client) resourceName(arn ARN) (string, bool) {
+	if client.service == ServiceSecrets {
+		name, ok := client.secretNames.Load(arn.String())
+
+		if !ok {
+			return "", false
+		}
+
+		return name.(string), true
+	}
+
+	name, err := arn.ParameterName()
+
+	return name, err == nil
+}
+
+// canonicalName maps an ARN in the caller's account to the name once the account is known,
+// so that a name and the ARN of the same parameter/secret share a cache entry.
+// Other ARNs never share an entry with a name, and unconfirmed secret ARNs are kept as is.
+func (client *client) canonicalName(nameOrARN string) string {
+	if !IsARN(nameOrARN) {
+		return nameOrARN
+	}
+
+	arn, err := ParseARN(nameOrARN)
+
+	if err != nil {
+		return nameOrARN
+	}
+
+	name, ok := client.resourceName(arn)
+
+	if !ok {
+		return nameOrARN
+	}
+
+	if caller := client.caller.Load(); caller != nil && *caller == *accountOf(arn) {
+		return name
+	}
+
+	return accountOf(arn).arn(client.service, name)
+}
+
+// cacheKey treats the same parameter/secret requested by full ARN, partial ARN (once confirmed) and name (once the caller's account is known) as one entry.
+func (client *client) cacheKey(query *url.Values) string {
+	canonical := url.Values{}
+
+	for key, values := range *query {
+		canonical[key] = values
+	}
+
+	canonical.Set(client.nameKey, client.canonicalName(query.Get(client.nameKey)))
+
+	return client.url.String() + "?" + canonical.Encode()
+}
+
+func (client *client) do(ctx context.Context, ev *Event, query *url.Values) ([]byte, error) {
+	budgetCtx, cancel, err := client.budgetContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cancel()
+	attemptCtx := budgetCtx
+
+	if client.retryClient == nil && client.attemptTimeout > 0 {
+		var cancelAttempt context.CancelFunc
+		attemptCtx, cancelAttempt = context.WithTimeout(budgetCtx, client.attemptTimeout)
+		defer cancelAttempt()
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, client.url.String(), nil)
 
 	if err != nil {
 		return nil, err
@@ -47,10 +289,15 @@ func (client *client) get(ctx context.Context, query *url.Values) ([]byte, error
 	res, err := client.HTTPClient.Do(req)
 
 	if err != nil {
+		if ctx.Err() == nil && budgetCtx.Err() != nil {
+			return nil, fmt.Errorf("%w (%s reserved before the invocation deadline): %w", ErrDeadlineBudgetExceeded, client.safetyBudget, err)
+		}
+
 		return nil, err
 	}
 
 	defer res.Body.Close()
+	ev.StatusCode = res.StatusCode
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
@@ -58,16 +305,135 @@ func (client *client) get(ctx context.Context, query *url.Values) ([]byte, error
 	}
 
 	if res.StatusCode != 200 {
-		text := res.Status
+		return nil, &HTTPError{StatusCode: res.StatusCode, Status: res.Status, Body: string(body)}
+	}
+
+	return body, nil
+}
 
-		if len(body) > 0 {
-			text += ": " + string(body)
+func (client *client) withRetry(retryMax int) {
+	retryClient := retryablehttp.NewClient()
+	retryClient.RetryMax = retryMax
+	retryClient.CheckRetry = retryPolicy
+	// The last response is returned as is, so that do() reports it as an HTTPError.
+	retryClient.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	retryClient.RequestLogHook = client.logRetry
+	retryClient.HTTPClient.Timeout = client.attemptTimeout
+	client.retryClient = retryClient
+	client.HTTPClient = retryClient.StandardClient()
+}
+
+func (client *client) withObserver(o Observer) {
+	if !slices.Contains(client.observers, o) {
+		client.observers = append(client.observers, o)
+	}
+}
+
+func (client *client) logRetry(_ retryablehttp.Logger, req *http.Request, attempt int) {
+	if attempt == 0 {
+		return
+	}
+
+	if ev, ok := req.Context().Value(eventKey{}).(*Event); ok {
+		ev.Attempt = attempt
+		client.notifyRetry(req.Context(), ev)
+	}
+}
+
+func (client *client) withDeadlineBudget(safety time.Duration, attemptTimeout time.Duration) {
+	client.safetyBudget = safety
+	client.attemptTimeout = attemptTimeout
+
+	if client.retryClient != nil {
+		client.retryClient.HTTPClient.Timeout = attemptTimeout
+	}
+}
+
+// budgetContext shortens the deadline set by the Lambda runtime by the safety budget,
+// so that retries give up while the handler still has time to return an error.
+func (client *client) budgetContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+
+	if !ok || client.safetyBudget <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	budgetDeadline := deadline.Add(-client.safetyBudget)
+
+	if time.Until(budgetDeadline) <= 0 {
+		return nil, nil, fmt.Errorf("%w (%s reserved before the invocation deadline): no time left for the request", ErrDeadlineBudgetExceeded, client.safetyBudget)
+	}
+
+	budgetCtx, cancel := context.WithDeadline(ctx, budgetDeadline)
+	return budgetCtx, cancel, nil
+}
+
+// WaitReady polls the extension with exponential backoff until it accepts connections and stops answering "not ready to serve traffic".
+// If ctx has no deadline, DefaultWaitReadyTimeout is used.
+func (client *client) WaitReady(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWaitReadyTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	interval := waitReadyMinInterval
+	lastStatus := "no response"
+
+	for {
+		ready, status := client.probe(ctx)
+
+		if ready {
+			return nil
 		}
 
-		return nil, errors.New(text)
+		lastStatus = status
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &WaitReadyTimeoutError{Waited: time.Since(start), LastStatus: lastStatus, Err: ctx.Err()}
+		case <-timer.C:
+		}
+
+		interval = min(interval*2, waitReadyMaxInterval)
 	}
+}
 
-	return body, nil
+func (client *client) probe(ctx context.Context) (bool, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url.String(), nil)
+
+	if err != nil {
+		return false, err.Error()
+	}
+
+	req.Header.Add("X-Aws-Parameters-Secrets-Token", os.Getenv("AWS_SESSION_TOKEN"))
+	res, err := client.probeClient().Do(req)
+
+	if err != nil {
+		return false, err.Error()
+	}
+
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if strings.Contains(string(body), notReadyMessage) {
+		return false, res.Status + ": " + string(body)
+	}
+
+	return true, res.Status
+}
+
+// probeClient returns HTTPClient, unwrapping the retry client set by WithRetry
+// because WaitReady does its own backoff.
+func (client *client) probeClient() *http.Client {
+	if rt, ok := client.HTTPClient.Transport.(*retryablehttp.RoundTripper); ok && rt.Client != nil {
+		return rt.Client.HTTPClient
+	}
+
+	return client.HTTPClient
 }
 
 func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
@@ -75,5 +441,35 @@ func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, err
 		return false, ctx.Err()
 	}
 
-	return resp.StatusCode == http.StatusBadRequest, nil
+	ev, _ := ctx.Value(eventKey{}).(*Event)
+
+	// Connection errors and per-attempt timeouts have no response.
+	if err != nil {
+		if ev != nil {
+			ev.RetryReason = "request error: " + err.Error()
+		}
+
+		return true, nil
+	}
+
+	// Other 400 responses (e.g. ResourceNotFoundException) are not retried.
+	if resp.StatusCode == http.StatusBadRequest && notReady(resp) {
+		if ev != nil {
+			ev.RetryReason = "status " + resp.Status
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// notReady reports whether the extension answered "not ready to serve traffic".
+// The body is restored, so it can still be read.
+func notReady(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return err == nil && strings.Contains(string(body), notReadyMessage)
 }
@@ -0,0 +1,253 @@
+package secretlamb_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestWaitReady(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	try := 0
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get", func(req *http.Request) (*http.Response, error) {
+		try++
+
+		if try < 3 {
+			return httpmock.NewStringResponse(http.StatusBadRequest, "not ready to serve traffic, please wait"), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusBadRequest, "missing secretId"), nil
+	})
+
+	s := secretlamb.MustNewSecrets()
+	err := s.WaitReady(context.Background())
+	require.NoError(err)
+	assert.Equal(3, try)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWaitReadyWithHTTPClient(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	try := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		try++
+		return httpmock.NewStringResponse(http.StatusBadRequest, "missing secretId"), nil
+	})
+
+	s := secretlamb.MustNewSecrets()
+	s.HTTPClient = &http.Client{Transport: transport}
+	require.NoError(s.WaitReady(context.Background()))
+	assert.Equal(1, try)
+
+	// the retry wrapper is bypassed, but its underlying client is used
+	s = secretlamb.MustNewSecrets().WithRetry(3)
+	called := false
+	s.HTTPClient.Transport.(*retryablehttp.RoundTripper).Client.HTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return httpmock.NewStringResponse(http.StatusBadRequest, "missing secretId"), nil
+	})
+	require.NoError(s.WaitReady(context.Background()))
+	assert.True(called)
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/", httpmock.NewStringResponder(http.StatusBadRequest, "not ready to serve traffic, please wait"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	p := secretlamb.MustNewParameters()
+	err := p.WaitReady(ctx)
+
+	var timeoutErr *secretlamb.WaitReadyTimeoutError
+	require.ErrorAs(err, &timeoutErr)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(timeoutErr.Waited, 100*time.Millisecond)
+	assert.Equal("400 Bad Request: not ready to serve traffic, please wait", timeoutErr.LastStatus)
+	assert.ErrorContains(err, "extension not ready after")
+}
+
+func TestDeadlineBudgetExhausted(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	s := secretlamb.MustNewSecrets().WithDeadlineBudget(200*time.Millisecond, 0)
+	_, err := s.GetWithContext(ctx, "foo", nil)
+	assert.ErrorIs(err, secretlamb.ErrDeadlineBudgetExceeded)
+	assert.ErrorContains(err, "failed to get secret - http request error: lambda deadline budget exceeded (200ms reserved before the invocation deadline)")
+	assert.Equal(0, httpmock.GetTotalCallCount())
+}
+
+func TestDeadlineBudgetCutShort(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo", func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	p := secretlamb.MustNewParameters().WithDeadlineBudget(100*time.Millisecond, 0)
+	start := time.Now()
+	_, err := p.GetWithContext(ctx, "foo")
+	assert.ErrorIs(err, secretlamb.ErrDeadlineBudgetExceeded)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Less(time.Since(start), 300*time.Millisecond)
+	assert.NoError(ctx.Err())
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo", func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	p := secretlamb.MustNewParameters().WithDeadlineBudget(0, 50*time.Millisecond)
+	_, err := p.Get("foo")
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.NotErrorIs(err, secretlamb.ErrDeadlineBudgetExceeded)
+}
+
+func TestAttemptTimeoutWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12775")
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	try := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		try++
+
+		if try == 1 {
+			time.Sleep(300 * time.Millisecond)
+		}
+
+		fmt.Fprint(w, parameterResponse(t, 1, "Veni"))
+	})
+
+	l, _ := net.Listen("tcp", ":12775")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	p := secretlamb.MustNewParameters().WithRetry(1).WithDeadlineBudget(0, 100*time.Millisecond)
+	value, err := p.Get("foo")
+	require.NoError(err)
+	assert.Equal(2, try)
+	assert.Equal("Veni", value.Parameter.Value)
+}
+
+func TestHTTPErrorNotFound(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo", httpmock.NewStringResponder(http.StatusBadRequest, `{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bar", httpmock.NewStringResponder(http.StatusBadRequest, "not ready to serve traffic, please wait"))
+
+	s := secretlamb.MustNewSecrets()
+	_, err := s.Get("foo")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+
+	_, err = s.Get("bar")
+	assert.NotErrorIs(err, secretlamb.ErrNotFound)
+	var httpErr *secretlamb.HTTPError
+	assert.ErrorAs(err, &httpErr)
+	assert.Equal(&secretlamb.HTTPError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: "not ready to serve traffic, please wait"}, httpErr)
+}
+
+func TestHTTPErrorWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12779")
+
+	assert := assert.New(t)
+
+	try := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		try++
+		w.WriteHeader(http.StatusBadRequest)
+
+		if r.URL.Query().Get("secretId") == "foo" {
+			fmt.Fprint(w, `{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`)
+		} else {
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
+		}
+	})
+
+	l, _ := net.Listen("tcp", ":12779")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	s := secretlamb.MustNewSecrets().WithRetry(1)
+
+	// not retried
+	_, err := s.Get("foo")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.Equal(1, try)
+
+	chain := secretlamb.NewSecretChain(
+		secretlamb.SecretSource{Name: "extension", Getter: s},
+		secretlamb.SecretSource{Name: "default", Getter: secretlamb.NewLocalSecrets(secretlamb.MapSource{"foo": "local"})},
+	)
+
+	chain.Policy = secretlamb.FallthroughOnNotFound
+	value, source, err := chain.GetWithSource(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal("local", value.SecretString)
+	assert.Equal("default", source)
+
+	// the last response is returned after the retries
+	try = 0
+	_, err = s.Get("bar")
+	var httpErr *secretlamb.HTTPError
+	assert.ErrorAs(err, &httpErr)
+	assert.Equal(&secretlamb.HTTPError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: "not ready to serve traffic, please wait"}, httpErr)
+	assert.Equal(2, try)
+}
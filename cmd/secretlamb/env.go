@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/winebarrel/secretlamb"
+)
+
+// env prints the resolved variables, e.g. eval "$(secretlamb env -format shell)".
+func env(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("env", flag.ExitOnError)
+	format := flags.String("format", "dotenv", "output format (dotenv, shell)")
+	prefix := flags.String("prefix", secretlamb.EnvMappingPrefix, "read NAME=REF from variables with this prefix when no NAME=REF is given")
+	_ = flags.Parse(args)
+
+	write := secretlamb.WriteDotenv
+
+	switch *format {
+	case "dotenv":
+	case "shell":
+		write = secretlamb.WriteShellExport
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	mapping := map[string]string{}
+
+	for _, arg := range flags.Args() {
+		name, ref, ok := strings.Cut(arg, "=")
+
+		if !ok {
+			return fmt.Errorf("invalid argument %q - expected NAME=REF", arg)
+		}
+
+		mapping[name] = ref
+	}
+
+	if len(mapping) == 0 {
+		mapping = secretlamb.EnvMapping(*prefix)
+	}
+
+	r, err := newResolver()
+
+	if err != nil {
+		return err
+	}
+
+	vars, err := r.ResolveEnv(ctx, mapping)
+
+	if err != nil {
+		return err
+	}
+
+	return write(os.Stdout, vars)
+}
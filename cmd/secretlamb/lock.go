@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/winebarrel/secretlamb"
+)
+
+// lock always reads versions through the extension, since local sources have no versions.
+// Outside the function environment, -port points it at a forwarded or emulated extension.
+func lock(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ExitOnError)
+	manifest := flags.String("manifest", secretlamb.DefaultManifestFile, "manifest listing the refs")
+	output := flags.String("o", secretlamb.DefaultLockfile, "lockfile to write")
+	port := flags.String("port", "", "port of the extension on localhost (default: $PARAMETERS_SECRETS_EXTENSION_HTTP_PORT or 2773)")
+	_ = flags.Parse(args)
+
+	if *port != "" {
+		os.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", *port)
+	}
+
+	refs, err := secretlamb.LoadManifest(*manifest)
+
+	if err != nil {
+		return err
+	}
+
+	p, err := secretlamb.NewParameters()
+
+	if err != nil {
+		return err
+	}
+
+	s, err := secretlamb.NewSecrets()
+
+	if err != nil {
+		return err
+	}
+
+	lockfile, err := secretlamb.NewResolver(p, s).Lock(ctx, refs)
+
+	if err != nil {
+		return err
+	}
+
+	return lockfile.WriteFile(*output)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/winebarrel/secretlamb"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]*command{
+	"env":         {usage: "env [-format dotenv|shell] [-prefix PREFIX] [NAME=REF...]", run: env},
+	"lock":        {usage: "lock [-manifest FILE] [-o FILE] [-port PORT] (reads versions through the extension)", run: lock},
+	"render":      {usage: "render [-dir DIR] [-mode MODE] TEMPLATE=OUTPUT...", run: render},
+	"write-files": {usage: "write-files [-dir DIR] [-mode MODE] [-interval DURATION] [-cleanup] PATH=REF...", run: writeFiles},
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+
+	if !ok {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	err := cmd.run(context.Background(), os.Args[2:])
+
+	if err != nil {
+		log.Fatalf("secretlamb %s: %s", os.Args[1], err)
+	}
+}
+
+func usage(w io.Writer) {
+	names := []string{}
+
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	fmt.Fprintln(w, "Usage:")
+
+	for _, name := range names {
+		fmt.Fprintln(w, "  secretlamb", commands[name].usage)
+	}
+}
+
+// Values are read through the extension in Lambda, otherwise from environment variables
+// (e.g. "/app/db-host" -> APP_DB_HOST).
+func newResolver() (*secretlamb.Resolver, error) {
+	return secretlamb.NewProviderResolver(&secretlamb.EnvSource{})
+}
+
+type fileMode os.FileMode
+
+func (m *fileMode) String() string {
+	return fmt.Sprintf("%04o", *m)
+}
+
+func (m *fileMode) Set(s string) error {
+	mode, err := strconv.ParseUint(s, 8, 32)
+
+	if err != nil {
+		return fmt.Errorf("invalid mode %q", s)
+	}
+
+	*m = fileMode(mode)
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/winebarrel/secretlamb"
+)
+
+func render(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	dir := flags.String("dir", os.TempDir(), "directory that outputs are written under")
+	mode := fileMode(0600)
+	flags.Var(&mode, "mode", "file mode of outputs")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return errors.New("no TEMPLATE=OUTPUT given")
+	}
+
+	r, err := newResolver()
+
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range flags.Args() {
+		tmplPath, outPath, ok := strings.Cut(arg, "=")
+
+		if !ok {
+			return fmt.Errorf("invalid argument %q - expected TEMPLATE=OUTPUT", arg)
+		}
+
+		outPath, err = pathUnder(*dir, outPath)
+
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := os.ReadFile(tmplPath)
+
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		err = r.Render(ctx, string(tmpl), &buf)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", tmplPath, err)
+		}
+
+		err = secretlamb.WriteFileAtomic(outPath, buf.Bytes(), os.FileMode(mode))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pathUnder resolves path relative to dir and refuses paths outside dir.
+func pathUnder(dir string, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s is not under %s", path, dir)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/winebarrel/secretlamb"
+)
+
+func writeFiles(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("write-files", flag.ExitOnError)
+	dir := flags.String("dir", os.TempDir(), "directory that files are written under")
+	mode := fileMode(secretlamb.DefaultFileMode)
+	flags.Var(&mode, "mode", "file mode of files")
+	interval := flags.Duration("interval", 0, "keep running and rewrite files whose version changed at this interval")
+	cleanup := flags.Bool("cleanup", false, "remove the files on SIGINT/SIGTERM (with -interval)")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return errors.New("no PATH=REF given")
+	} else if *cleanup && *interval <= 0 {
+		return errors.New("-cleanup requires -interval")
+	}
+
+	mapping := map[string]string{}
+
+	for _, arg := range flags.Args() {
+		path, ref, ok := strings.Cut(arg, "=")
+
+		if !ok {
+			return fmt.Errorf("invalid argument %q - expected PATH=REF", arg)
+		}
+
+		path, err := pathUnder(*dir, path)
+
+		if err != nil {
+			return err
+		}
+
+		mapping[path] = ref
+	}
+
+	r, err := newResolver()
+
+	if err != nil {
+		return err
+	}
+
+	w := secretlamb.NewFileWriter(r)
+	w.Mode = os.FileMode(mode)
+	_, err = w.WriteFiles(ctx, mapping)
+
+	if err != nil || *interval <= 0 {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if *cleanup {
+				return w.Cleanup()
+			}
+
+			return nil
+		case <-ticker.C:
+			written, err := w.WriteFiles(ctx, mapping)
+
+			if err != nil {
+				log.Println(err)
+			}
+
+			for _, path := range written {
+				log.Printf("rewrote %s", path)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package secretlamb
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MaxDecodedSize limits the size of each decoded layer to guard against decompression bombs.
+var MaxDecodedSize int64 = 4 << 20
+
+// MaxDecodeLayers limits how many encodings are peeled off when they are detected.
+var MaxDecodeLayers = 4
+
+var ErrDecodedSizeExceeded = errors.New("decoded size exceeded")
+
+type DecodeFunc func(r io.Reader) (io.Reader, error)
+
+type codec struct {
+	magic  []byte
+	decode DecodeFunc
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]*codec{}
+)
+
+func init() {
+	RegisterCodec("base64", nil, func(r io.Reader) (io.Reader, error) {
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	})
+
+	RegisterCodec("base64url", nil, func(r io.Reader) (io.Reader, error) {
+		return base64.NewDecoder(base64.URLEncoding, r), nil
+	})
+
+	RegisterCodec("gzip", []byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+
+	// Only the default compression level is detected.
+	RegisterCodec("zlib", []byte{0x78, 0x9c}, func(r io.Reader) (io.Reader, error) {
+		return zlib.NewReader(r)
+	})
+
+	RegisterCodec("deflate", nil, func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	})
+
+	RegisterCodec("zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(MaxDecodedSize)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return d.IOReadCloser(), nil
+	})
+}
+
+// RegisterCodec adds or replaces a codec. With magic, the codec is also used when encodings are detected.
+// e.g. brotli with github.com/andybalholm/brotli:
+//
+//	secretlamb.RegisterCodec("br", nil, func(r io.Reader) (io.Reader, error) {
+//		return brotli.NewReader(r), nil
+//	})
+func RegisterCodec(name string, magic []byte, decode DecodeFunc) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = &codec{magic: magic, decode: decode}
+}
+
+// Decode applies encodings in order, e.g. Decode(data, "base64", "gzip").
+// Without encodings, base64 and codecs with a magic number are detected.
+func Decode(data []byte, encodings ...string) ([]byte, error) {
+	if len(encodings) == 0 {
+		return decodeDetected(data)
+	}
+
+	for _, name := range encodings {
+		codecsMu.RLock()
+		c, ok := codecs[name]
+		codecsMu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("unknown encoding %q", name)
+		}
+
+		var err error
+		data, err = decodeWith(name, c, data)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func decodeWith(name string, c *codec, data []byte) ([]byte, error) {
+	r, err := c.decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s decode error: %w", name, err)
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(r, MaxDecodedSize+1))
+
+	if closer, ok := r.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s decode error: %w", name, err)
+	} else if int64(len(decoded)) > MaxDecodedSize {
+		return nil, fmt.Errorf("%s decode error: %w: more than %d bytes", name, ErrDecodedSizeExceeded, MaxDecodedSize)
+	}
+
+	return decoded, nil
+}
+
+func decodeDetected(data []byte) ([]byte, error) {
+	for range MaxDecodeLayers {
+		name, c := detectCodec(data)
+
+		if c == nil {
+			if decoded, ok := detectBase64(data); ok {
+				data = decoded
+				continue
+			}
+
+			return data, nil
+		}
+
+		var err error
+		data, err = decodeWith(name, c, data)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func detectCodec(data []byte) (string, *codec) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	for name, c := range codecs {
+		if len(c.magic) > 0 && bytes.HasPrefix(data, c.magic) {
+			return name, c
+		}
+	}
+
+	return "", nil
+}
+
+// detectBase64 only accepts base64 whose decoded bytes start with a known magic number,
+// because plain text is often valid base64.
+func detectBase64(data []byte) ([]byte, bool) {
+	text := strings.TrimSpace(string(data))
+
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(text)
+
+		if err != nil {
+			continue
+		}
+
+		if _, c := detectCodec(decoded); c != nil {
+			return decoded, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParameterDecode decodes the value with encodings in order, or detects them when none are given.
+func ParameterDecode(encodings ...string) *ParameterOption {
+	return &ParameterOption{
+		transform: func(ctx context.Context, output *ParameterOutput) error {
+			decoded, err := Decode([]byte(output.Parameter.Value), encodings...)
+
+			if err != nil {
+				return fmt.Errorf("decode error: %w", err)
+			}
+
+			output.Parameter.Value = string(decoded)
+			return nil
+		},
+	}
+}
+
+// SecretDecode decodes the secret string with encodings in order, or detects them when none are given.
+// It is applied before a "#key" selector.
+func SecretDecode(encodings ...string) *SecretOption {
+	return &SecretOption{
+		transform: func(ctx context.Context, output *SecretOutput) error {
+			decoded, err := Decode([]byte(output.SecretString), encodings...)
+
+			if err != nil {
+				return fmt.Errorf("decode error: %w", err)
+			}
+
+			output.SecretString = string(decoded)
+			return nil
+		},
+	}
+}
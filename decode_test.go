@@ -0,0 +1,161 @@
+package secretlamb_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func gzipString(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	gz := gzipString(t, "hello")
+
+	var zbuf bytes.Buffer
+	zw := zlib.NewWriter(&zbuf)
+	zw.Write([]byte("zlib"))
+	zw.Close()
+
+	decoded, err := secretlamb.Decode([]byte(base64.StdEncoding.EncodeToString(gz)), "base64", "gzip")
+	require.NoError(err)
+	assert.Equal("hello", string(decoded))
+
+	decoded, err = secretlamb.Decode([]byte(base64.URLEncoding.EncodeToString([]byte("a?b>"))), "base64url")
+	require.NoError(err)
+	assert.Equal("a?b>", string(decoded))
+
+	// detected
+	decoded, err = secretlamb.Decode([]byte(base64.StdEncoding.EncodeToString(gz)))
+	require.NoError(err)
+	assert.Equal("hello", string(decoded))
+
+	decoded, err = secretlamb.Decode(zbuf.Bytes())
+	require.NoError(err)
+	assert.Equal("zlib", string(decoded))
+
+	// plain text that is also valid base64 is left as is
+	decoded, err = secretlamb.Decode([]byte("abcd"))
+	require.NoError(err)
+	assert.Equal("abcd", string(decoded))
+
+	_, err = secretlamb.Decode([]byte("abcd"), "rot13")
+	assert.ErrorContains(err, `unknown encoding "rot13"`)
+}
+
+func TestDecodeSizeLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	bomb := gzipString(t, strings.Repeat("0", int(secretlamb.MaxDecodedSize)+1))
+	_, err := secretlamb.Decode(bomb)
+	assert.ErrorIs(err, secretlamb.ErrDecodedSizeExceeded)
+}
+
+func TestDecodeZstd(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(err)
+	zst := enc.EncodeAll([]byte("zstd"), nil)
+	enc.Close()
+
+	decoded, err := secretlamb.Decode(zst, "zstd")
+	require.NoError(err)
+	assert.Equal("zstd", string(decoded))
+
+	// detected
+	decoded, err = secretlamb.Decode([]byte(base64.StdEncoding.EncodeToString(zst)))
+	require.NoError(err)
+	assert.Equal("zstd", string(decoded))
+
+	_, err = secretlamb.Decode([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, "zstd")
+	assert.ErrorContains(err, "zstd decode error")
+}
+
+func TestRegisterCodec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	secretlamb.RegisterCodec("upper", []byte("UP:"), func(r io.Reader) (io.Reader, error) {
+		b, err := io.ReadAll(r)
+		return strings.NewReader(strings.ToUpper(string(b[3:]))), err
+	})
+
+	decoded, err := secretlamb.Decode([]byte("UP:hello"))
+	require.NoError(err)
+	assert.Equal("HELLO", string(decoded))
+}
+
+func TestParametersGetDecode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	value := base64.StdEncoding.EncodeToString(gzipString(t, "big config"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, value)))
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.Get("foo", secretlamb.ParameterDecode("base64", "gzip"))
+	require.NoError(err)
+	assert.Equal("big config", output.Parameter.Value)
+
+	output, err = p.Get("foo", secretlamb.ParameterDecode())
+	require.NoError(err)
+	assert.Equal("big config", output.Parameter.Value)
+
+	_, err = p.Get("foo", secretlamb.ParameterDecode("gzip"))
+	assert.ErrorContains(err, "failed to get parameter - decode error: gzip decode error")
+}
+
+func TestSecretsGetDecodeWithJSONKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	value := base64.StdEncoding.EncodeToString(gzipString(t, `{"password":"tiger"}`))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", value)))
+
+	s := secretlamb.MustNewSecrets()
+	output, err := s.Get("prod/db#password", secretlamb.SecretDecode())
+	require.NoError(err)
+	assert.Equal("tiger", output.SecretString)
+}
+
+func TestParametersGetChunkedDecode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	value := base64.StdEncoding.EncodeToString(gzipString(t, "big config"))
+	registerChunks(t, "/app/big", []int64{1, 1}, []string{value[:10], value[10:]})
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.GetChunked(context.Background(), "/app/big", secretlamb.ParameterDecode())
+	require.NoError(err)
+	assert.Equal("big config", output.Value)
+}
@@ -0,0 +1,262 @@
+package secretlamb
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+// Decrypter decrypts values encrypted by the application before they were stored.
+type Decrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+type DecrypterFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+func (f DecrypterFunc) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return f(ctx, ciphertext)
+}
+
+// KeySource provides key material to a Decrypter. The key is fetched on every Decrypt,
+// so use a client with WithCache to avoid extra requests.
+type KeySource interface {
+	FetchKey(ctx context.Context) ([]byte, error)
+}
+
+type StaticKey []byte
+
+func (k StaticKey) FetchKey(ctx context.Context) ([]byte, error) {
+	return k, nil
+}
+
+type secretKey struct {
+	secrets  SecretGetter
+	secretId string
+	options  []*SecretOption
+}
+
+// SecretKey reads a base64-encoded key from a secret, e.g. SecretKey(s, "keys/app#data").
+// The options may include SecretDecrypt, so the key itself can be encrypted with another key.
+func SecretKey(s SecretGetter, secretId string, options ...*SecretOption) KeySource {
+	return &secretKey{secrets: s, secretId: secretId, options: options}
+}
+
+func (src *secretKey) FetchKey(ctx context.Context) ([]byte, error) {
+	output, err := src.secrets.GetWithContext(ctx, src.secretId, src.options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeKey(output.SecretString)
+}
+
+type parameterKey struct {
+	parameters ParameterGetter
+	name       string
+	options    []*ParameterOption
+}
+
+// ParameterKey reads a base64-encoded key from a SecureString parameter.
+func ParameterKey(p ParameterGetter, name string, options ...*ParameterOption) KeySource {
+	options = append([]*ParameterOption{ParameterWithDecryption()}, options...)
+	return &parameterKey{parameters: p, name: name, options: options}
+}
+
+func (src *parameterKey) FetchKey(ctx context.Context) ([]byte, error) {
+	output, err := src.parameters.GetWithContext(ctx, src.name, src.options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeKey(output.Parameter.Value)
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+
+	if err != nil {
+		// The key itself must not appear in the error.
+		return nil, errors.New("key is not base64-encoded")
+	}
+
+	return key, nil
+}
+
+// AESGCMDecrypter decrypts nonce (12 bytes) || ciphertext || tag with a 16, 24 or 32-byte key.
+type AESGCMDecrypter struct {
+	Key KeySource
+}
+
+func NewAESGCMDecrypter(key KeySource) *AESGCMDecrypter {
+	return &AESGCMDecrypter{Key: key}
+}
+
+func (d *AESGCMDecrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	key, err := d.Key.FetchKey(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("fetch key error: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("aes error: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, fmt.Errorf("aes-gcm error: %w", err)
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("aes-gcm error: %w: ciphertext too short", ErrDecryptionFailed)
+	}
+
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("aes-gcm error: %w", ErrDecryptionFailed)
+	}
+
+	return plaintext, nil
+}
+
+// SecretboxDecrypter decrypts nonce (24 bytes) || box of NaCl secretbox with a 32-byte key.
+type SecretboxDecrypter struct {
+	Key KeySource
+}
+
+func NewSecretboxDecrypter(key KeySource) *SecretboxDecrypter {
+	return &SecretboxDecrypter{Key: key}
+}
+
+func (d *SecretboxDecrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	key, err := d.Key.FetchKey(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("fetch key error: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secretbox error: invalid key size %d", len(key))
+	}
+
+	if len(ciphertext) < 24+secretbox.Overhead {
+		return nil, fmt.Errorf("secretbox error: %w: ciphertext too short", ErrDecryptionFailed)
+	}
+
+	var k [32]byte
+	var nonce [24]byte
+	copy(k[:], key)
+	copy(nonce[:], ciphertext[:24])
+	plaintext, ok := secretbox.Open(nil, ciphertext[24:], &nonce, &k)
+
+	if !ok {
+		return nil, fmt.Errorf("secretbox error: %w", ErrDecryptionFailed)
+	}
+
+	return plaintext, nil
+}
+
+type skipDecrypterKey struct{}
+
+// withoutDecrypter marks ctx so that c skips the decrypter set by WithDecrypter.
+func (c *client) withoutDecrypter(ctx context.Context) context.Context {
+	skipped, _ := ctx.Value(skipDecrypterKey{}).([]*client)
+
+	if slices.Contains(skipped, c) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, skipDecrypterKey{}, append(skipped[:len(skipped):len(skipped)], c))
+}
+
+// clientDecrypter returns the decrypter set by WithDecrypter, or nil when ctx is marked by withoutDecrypter.
+// Keys are fetched without it, so a key read through c does not recurse.
+func (c *client) clientDecrypter(ctx context.Context) Decrypter {
+	skipped, _ := ctx.Value(skipDecrypterKey{}).([]*client)
+
+	if c.decrypter == nil || slices.Contains(skipped, c) {
+		return nil
+	}
+
+	return DecrypterFunc(func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		return c.decrypter.Decrypt(c.withoutDecrypter(ctx), ciphertext)
+	})
+}
+
+// undecryptedParameters reads parameters without the decrypter set by WithDecrypter,
+// e.g. chunks and manifests, which are decrypted after being reassembled, and public parameters.
+type undecryptedParameters struct {
+	*Parameters
+}
+
+func (u undecryptedParameters) Get(name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return u.GetWithContext(context.Background(), name, options...)
+}
+
+func (u undecryptedParameters) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return u.Parameters.GetWithContext(u.withoutDecrypter(ctx), name, options...)
+}
+
+func decryptValue(ctx context.Context, d Decrypter, value string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+
+	if err != nil {
+		return "", fmt.Errorf("decrypt error: ciphertext is not base64-encoded: %w", err)
+	}
+
+	plaintext, err := d.Decrypt(ctx, ciphertext)
+
+	if err != nil {
+		return "", fmt.Errorf("decrypt error: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// ParameterDecrypt decrypts the base64-encoded value with d.
+func ParameterDecrypt(d Decrypter) *ParameterOption {
+	return &ParameterOption{
+		transform: func(ctx context.Context, output *ParameterOutput) error {
+			value, err := decryptValue(ctx, d, output.Parameter.Value)
+
+			if err != nil {
+				return err
+			}
+
+			output.Parameter.Value = value
+			return nil
+		},
+	}
+}
+
+// SecretDecrypt decrypts the base64-encoded secret string with d.
+// It is applied before a "#key" selector.
+func SecretDecrypt(d Decrypter) *SecretOption {
+	return &SecretOption{
+		transform: func(ctx context.Context, output *SecretOutput) error {
+			value, err := decryptValue(ctx, d, output.SecretString)
+
+			if err != nil {
+				return err
+			}
+
+			output.SecretString = value
+			return nil
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package secretlamb_test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func sealAESGCM(t *testing.T, key []byte, plaintext string) string {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func sealSecretbox(t *testing.T, key []byte, plaintext string) string {
+	var k [32]byte
+	var nonce [24]byte
+	copy(k[:], key)
+	_, err := rand.Read(nonce[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(secretbox.Seal(nonce[:], []byte(plaintext), &nonce, &k))
+}
+
+func TestDecrypters(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	key := newTestKey(t)
+
+	aesValue, _ := base64.StdEncoding.DecodeString(sealAESGCM(t, key, "aes"))
+	plaintext, err := secretlamb.NewAESGCMDecrypter(secretlamb.StaticKey(key)).Decrypt(context.Background(), aesValue)
+	require.NoError(err)
+	assert.Equal("aes", string(plaintext))
+
+	boxValue, _ := base64.StdEncoding.DecodeString(sealSecretbox(t, key, "box"))
+	plaintext, err = secretlamb.NewSecretboxDecrypter(secretlamb.StaticKey(key)).Decrypt(context.Background(), boxValue)
+	require.NoError(err)
+	assert.Equal("box", string(plaintext))
+
+	_, err = secretlamb.NewAESGCMDecrypter(secretlamb.StaticKey(newTestKey(t))).Decrypt(context.Background(), aesValue)
+	assert.ErrorIs(err, secretlamb.ErrDecryptionFailed)
+
+	_, err = secretlamb.NewSecretboxDecrypter(secretlamb.StaticKey(newTestKey(t))).Decrypt(context.Background(), boxValue)
+	assert.ErrorIs(err, secretlamb.ErrDecryptionFailed)
+}
+
+func TestParametersGetDecryptWithSecretKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	masterKey := newTestKey(t)
+	dataKey := newTestKey(t)
+
+	// The data key is itself encrypted with the master key.
+	encryptedDataKey := sealSecretbox(t, masterKey, base64.StdEncoding.EncodeToString(dataKey))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=keys%2Fapp", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", `{"data":"`+encryptedDataKey+`"}`)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fdsn", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, sealAESGCM(t, dataKey, "postgres://scott:tiger@db"))))
+
+	s := secretlamb.MustNewSecrets()
+	keyDecrypter := secretlamb.NewSecretboxDecrypter(secretlamb.StaticKey(masterKey))
+	dataKeySource := secretlamb.SecretKey(s, "keys/app", secretlamb.SecretJSONKey("data"), secretlamb.SecretDecrypt(keyDecrypter))
+	d := secretlamb.NewAESGCMDecrypter(dataKeySource)
+
+	p := secretlamb.MustNewParameters()
+	output, err := p.Get("/app/dsn", secretlamb.ParameterDecrypt(d))
+	require.NoError(err)
+	assert.Equal("postgres://scott:tiger@db", output.Parameter.Value)
+
+	output, err = p.WithDecrypter(d).Get("/app/dsn")
+	require.NoError(err)
+	assert.Equal("postgres://scott:tiger@db", output.Parameter.Value)
+}
+
+func TestSecretsWithDecrypter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	key := newTestKey(t)
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", sealAESGCM(t, key, `{"password":"tiger"}`))))
+
+	s := secretlamb.MustNewSecrets().WithDecrypter(secretlamb.NewAESGCMDecrypter(secretlamb.StaticKey(key)))
+	output, err := s.Get("prod/db#password")
+	require.NoError(err)
+	assert.Equal("tiger", output.SecretString)
+
+	s = secretlamb.MustNewSecrets().WithDecrypter(secretlamb.NewAESGCMDecrypter(secretlamb.StaticKey(newTestKey(t))))
+	_, err = s.Get("prod/db")
+	assert.ErrorIs(err, secretlamb.ErrDecryptionFailed)
+	assert.ErrorContains(err, "failed to get secret - decrypt error: aes-gcm error: decryption failed")
+}
+
+func TestSecretsWithDecrypterKeyFromSameClient(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	key := newTestKey(t)
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=keys%2Fapp", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", base64.StdEncoding.EncodeToString(key))))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", sealAESGCM(t, key, "tiger"))))
+
+	// The key is read through s itself without being decrypted.
+	s := secretlamb.MustNewSecrets()
+	s.WithDecrypter(secretlamb.NewAESGCMDecrypter(secretlamb.SecretKey(s, "keys/app")))
+	output, err := s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("tiger", output.SecretString)
+}
+
+func TestParametersGetChunkedWithDecrypter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	key := newTestKey(t)
+	ciphertext := sealAESGCM(t, key, "postgres://scott:tiger@db")
+	values := []string{ciphertext[:10], ciphertext[10:20], ciphertext[20:]}
+	registerChunks(t, "/app/big", []int64{1, 1, 1}, values)
+
+	sum := sha256.Sum256([]byte(ciphertext))
+	manifest := fmt.Sprintf(`{"count":3,"sha256":"%s"}`, hex.EncodeToString(sum[:]))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fbig%2Fmanifest", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, manifest)))
+
+	body, err := json.Marshal(publicParameter("/aws/service/global-infrastructure/services/lambda/longName", "AWS Lambda", "text"))
+	require.NoError(err)
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Faws%2Fservice%2Fglobal-infrastructure%2Fservices%2Flambda%2FlongName", httpmock.NewBytesResponder(http.StatusOK, body))
+
+	p := secretlamb.MustNewParameters().WithDecrypter(secretlamb.NewAESGCMDecrypter(secretlamb.StaticKey(key)))
+	output, err := p.GetChunked(context.Background(), "/app/big")
+	require.NoError(err)
+	assert.Equal("postgres://scott:tiger@db", output.Value)
+	assert.Equal(values[0], output.Chunks[0].Parameter.Value)
+
+	// Public parameters are not decrypted.
+	longName, err := p.Public().ServiceLongName(context.Background(), "lambda")
+	require.NoError(err)
+	assert.Equal("AWS Lambda", longName)
+}
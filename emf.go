@@ -0,0 +1,179 @@
+package secretlamb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CloudWatch accepts up to 100 values per metric in one EMF document.
+const emfMaxValues = 100
+
+// EMFEmitter aggregates lookup metrics per service and writes them
+// as CloudWatch Embedded Metric Format JSON lines on Flush.
+type EMFEmitter struct {
+	Namespace string
+	Writer    io.Writer
+	mu        sync.Mutex
+	stats     map[string]*emfStats
+}
+
+type emfStats struct {
+	lookups   int
+	cacheHits int
+	retries   int
+	failures  int
+	latencies []float64
+}
+
+func NewEMFEmitter(namespace string) *EMFEmitter {
+	return &EMFEmitter{
+		Namespace: namespace,
+		Writer:    os.Stdout,
+		stats:     map[string]*emfStats{},
+	}
+}
+
+func (e *EMFEmitter) statsFor(service string) *emfStats {
+	stats, ok := e.stats[service]
+
+	if !ok {
+		stats = &emfStats{}
+		e.stats[service] = stats
+	}
+
+	return stats
+}
+
+func (e *EMFEmitter) OnRequest(ctx context.Context, ev *Event) (context.Context, error) {
+	return ctx, nil
+}
+
+func (e *EMFEmitter) OnRetry(ctx context.Context, ev *Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.statsFor(ev.Service).retries++
+}
+
+func (e *EMFEmitter) OnResponse(ctx context.Context, ev *Event) {
+	if ev.CacheHit {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		stats := e.statsFor(ev.Service)
+		stats.lookups++
+		stats.cacheHits++
+		return
+	}
+
+	e.record(ev, false)
+}
+
+func (e *EMFEmitter) OnError(ctx context.Context, ev *Event) {
+	e.record(ev, true)
+}
+
+func (e *EMFEmitter) record(ev *Event, failed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := e.statsFor(ev.Service)
+	stats.lookups++
+	stats.latencies = append(stats.latencies, float64(ev.Duration.Microseconds())/1000)
+
+	if failed {
+		stats.failures++
+	}
+
+	if len(stats.latencies) >= emfMaxValues {
+		_ = e.flush(ev.Service)
+	}
+}
+
+// Flush writes one line per service and resets the counters. Call it at the end of each invocation.
+func (e *EMFEmitter) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	services := []string{}
+
+	for service := range e.stats {
+		services = append(services, service)
+	}
+
+	slices.Sort(services)
+
+	for _, service := range services {
+		if err := e.flush(service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+func (e *EMFEmitter) flush(service string) error {
+	stats := e.stats[service]
+	delete(e.stats, service)
+
+	if stats == nil || stats.lookups == 0 {
+		return nil
+	}
+
+	metrics := []emfMetric{
+		{Name: "Lookups", Unit: "Count"},
+		{Name: "CacheHits", Unit: "Count"},
+		{Name: "CacheHitRatio", Unit: "Percent"},
+		{Name: "Retries", Unit: "Count"},
+		{Name: "Failures", Unit: "Count"},
+	}
+
+	doc := map[string]any{
+		"Service":       service,
+		"Lookups":       stats.lookups,
+		"CacheHits":     stats.cacheHits,
+		"CacheHitRatio": float64(stats.cacheHits) / float64(stats.lookups) * 100,
+		"Retries":       stats.retries,
+		"Failures":      stats.failures,
+	}
+
+	if len(stats.latencies) > 0 {
+		metrics = append(metrics, emfMetric{Name: "Latency", Unit: "Milliseconds"})
+		doc["Latency"] = stats.latencies
+	}
+
+	doc["_aws"] = emfMetadata{
+		Timestamp: time.Now().UnixMilli(),
+		CloudWatchMetrics: []emfDirective{{
+			Namespace:  e.Namespace,
+			Dimensions: [][]string{{"Service"}},
+			Metrics:    metrics,
+		}},
+	}
+
+	line, err := json.Marshal(doc)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Writer.Write(append(line, '\n'))
+	return err
+}
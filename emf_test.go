@@ -0,0 +1,146 @@
+package secretlamb_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func readEMFLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	lines := []map[string]any{}
+	scanner := bufio.NewScanner(buf)
+
+	for scanner.Scan() {
+		line := map[string]any{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		delete(line["_aws"].(map[string]any), "Timestamp")
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestEMFEmitter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "Veni")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=bar", httpmock.NewStringResponder(http.StatusBadRequest, "ParameterNotFound"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "Vidi")))
+
+	var buf bytes.Buffer
+	emitter := secretlamb.NewEMFEmitter("MyApp")
+	emitter.Writer = &buf
+
+	p := secretlamb.MustNewParameters().WithCache(secretlamb.NewCache(0)).WithEMF(emitter)
+	s := secretlamb.MustNewSecrets().WithEMF(emitter)
+
+	_, err := p.Get("foo")
+	require.NoError(err)
+	_, err = p.Get("foo")
+	require.NoError(err)
+	_, err = p.Get("bar")
+	require.Error(err)
+	_, err = s.Get("foo")
+	require.NoError(err)
+
+	require.NoError(emitter.Flush())
+	lines := readEMFLines(t, &buf)
+	require.Len(lines, 2)
+
+	for _, line := range lines {
+		assert.Len(line["Latency"], int(line["Lookups"].(float64)-line["CacheHits"].(float64)))
+		delete(line, "Latency")
+	}
+
+	metrics := []any{
+		map[string]any{"Name": "Lookups", "Unit": "Count"},
+		map[string]any{"Name": "CacheHits", "Unit": "Count"},
+		map[string]any{"Name": "CacheHitRatio", "Unit": "Percent"},
+		map[string]any{"Name": "Retries", "Unit": "Count"},
+		map[string]any{"Name": "Failures", "Unit": "Count"},
+		map[string]any{"Name": "Latency", "Unit": "Milliseconds"},
+	}
+
+	assert.Equal([]map[string]any{
+		{
+			"_aws": map[string]any{
+				"CloudWatchMetrics": []any{map[string]any{"Namespace": "MyApp", "Dimensions": []any{[]any{"Service"}}, "Metrics": metrics}},
+			},
+			"Service":       "secretsmanager",
+			"Lookups":       float64(1),
+			"CacheHits":     float64(0),
+			"CacheHitRatio": float64(0),
+			"Retries":       float64(0),
+			"Failures":      float64(0),
+		},
+		{
+			"_aws": map[string]any{
+				"CloudWatchMetrics": []any{map[string]any{"Namespace": "MyApp", "Dimensions": []any{[]any{"Service"}}, "Metrics": metrics}},
+			},
+			"Service":       "ssm",
+			"Lookups":       float64(3),
+			"CacheHits":     float64(1),
+			"CacheHitRatio": float64(1) / 3 * 100,
+			"Retries":       float64(0),
+			"Failures":      float64(1),
+		},
+	}, lines)
+
+	require.NoError(emitter.Flush())
+	assert.Empty(buf.String())
+}
+
+func TestEMFEmitterWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12776")
+	assert := assert.New(t)
+	require := require.New(t)
+
+	try := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		try++
+
+		if try == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
+			return
+		}
+
+		fmt.Fprint(w, secretResponse(t, "v1", "Vidi"))
+	})
+
+	l, _ := net.Listen("tcp", ":12776")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	emitter := secretlamb.NewEMFEmitter("MyApp")
+	emitter.Writer = &buf
+
+	s := secretlamb.MustNewSecrets().WithRetry(1).WithEMF(emitter)
+	_, err := s.Get("foo")
+	require.NoError(err)
+	require.NoError(emitter.Flush())
+
+	lines := readEMFLines(t, &buf)
+	require.Len(lines, 1)
+	assert.Equal(float64(1), lines[0]["Retries"])
+	assert.Equal(float64(1), lines[0]["Lookups"])
+}
@@ -0,0 +1,139 @@
+package secretlamb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvMappingPrefix marks environment variables that hold refs, e.g. SECRETLAMB_ENV_DB_PASS=secret:prod/db#password sets DB_PASS.
+const EnvMappingPrefix = "SECRETLAMB_ENV_"
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ApplyEnv resolves mapping (variable name -> ref) with DefaultResolver and sets the variables.
+func ApplyEnv(ctx context.Context, mapping map[string]string) error {
+	r, err := defaultResolver()
+
+	if err != nil {
+		return fmt.Errorf("failed to apply env - %w", err)
+	}
+
+	return r.ApplyEnv(ctx, mapping)
+}
+
+// ApplyEnvFromPrefix applies the mapping read by EnvMapping(prefix).
+func ApplyEnvFromPrefix(ctx context.Context, prefix string) error {
+	return ApplyEnv(ctx, EnvMapping(prefix))
+}
+
+// EnvMapping returns the variables with prefix, with the prefix removed from the names.
+func EnvMapping(prefix string) map[string]string {
+	mapping := map[string]string{}
+
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			mapping[strings.TrimPrefix(name, prefix)] = value
+		}
+	}
+
+	return mapping
+}
+
+// ApplyEnv sets no variables unless every ref is resolved.
+func (r *Resolver) ApplyEnv(ctx context.Context, mapping map[string]string) error {
+	vars, err := r.ResolveEnv(ctx, mapping)
+
+	if err != nil {
+		return err
+	}
+
+	for name, value := range vars {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to apply env - %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ResolveEnv resolves mapping (variable name -> ref) without setting the variables.
+func (r *Resolver) ResolveEnv(ctx context.Context, mapping map[string]string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	for _, name := range sortedKeys(mapping) {
+		if !envNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("failed to apply env - invalid variable name %q", name)
+		}
+
+		value, err := r.ResolveString(ctx, mapping[name])
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply env - %s: %w", name, err)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteDotenv writes KEY='value' lines that LoadDotenvFile reads back.
+// Single quotes keep docker compose and godotenv from expanding $VAR in values.
+// Values with a single quote or a line break are written as KEY="value" with Go escapes.
+func WriteDotenv(w io.Writer, vars map[string]string) error {
+	for _, name := range sortedKeys(vars) {
+		if !envNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+
+		value := vars[name]
+
+		if strings.ContainsAny(value, "'\r\n") {
+			value = strconv.Quote(value)
+		} else {
+			value = "'" + value + "'"
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteShellExport writes export KEY='value' lines for eval in sh/bash.
+func WriteShellExport(w io.Writer, vars map[string]string) error {
+	for _, name := range sortedKeys(vars) {
+		if !envNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+
+		value := "'" + strings.ReplaceAll(vars[name], "'", `'\''`) + "'"
+
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
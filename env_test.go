@@ -0,0 +1,99 @@
+package secretlamb_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestApplyEnv(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t.Setenv("SECRETLAMB_PROVIDER", "local")
+	t.Setenv("APP_HOST", "db.example.com")
+	t.Setenv("PROD_DB", `{"password":"tiger"}`)
+	t.Setenv("DB_PASS", "")
+	t.Setenv("DB_HOST", "")
+
+	err := secretlamb.ApplyEnv(context.Background(), map[string]string{
+		"DB_PASS": "secret:prod/db#password",
+		"DB_HOST": "ssm:/app/host",
+	})
+	require.NoError(err)
+	assert.Equal("tiger", os.Getenv("DB_PASS"))
+	assert.Equal("db.example.com", os.Getenv("DB_HOST"))
+}
+
+func TestApplyEnvFromPrefix(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	secretlamb.DefaultResolver = secretlamb.NewResolver(
+		secretlamb.NewFakeParameters(map[string]string{"/app/host": "db.example.com"}),
+		secretlamb.NewFakeSecrets(map[string]string{"prod/db": `{"password":"tiger"}`}),
+	)
+
+	defer func() { secretlamb.DefaultResolver = nil }()
+
+	t.Setenv("SECRETLAMB_ENV_DB_PASS", "secret:prod/db#password")
+	t.Setenv("DB_PASS", "")
+
+	err := secretlamb.ApplyEnvFromPrefix(context.Background(), secretlamb.EnvMappingPrefix)
+	require.NoError(err)
+	assert.Equal("tiger", os.Getenv("DB_PASS"))
+}
+
+func TestApplyEnvErr(t *testing.T) {
+	assert := assert.New(t)
+
+	r := secretlamb.NewResolver(
+		secretlamb.NewFakeParameters(map[string]string{"/app/host": "db.example.com"}),
+		secretlamb.NewFakeSecrets(nil),
+	)
+
+	t.Setenv("DB_HOST", "old")
+
+	// nothing is set when a ref cannot be resolved
+	err := r.ApplyEnv(context.Background(), map[string]string{
+		"DB_HOST": "ssm:/app/host",
+		"DB_PASS": "secret:prod/db#password",
+	})
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.ErrorContains(err, "failed to apply env - DB_PASS")
+	assert.Equal("old", os.Getenv("DB_HOST"))
+
+	err = r.ApplyEnv(context.Background(), map[string]string{"DB-HOST": "ssm:/app/host"})
+	assert.ErrorContains(err, `invalid variable name "DB-HOST"`)
+}
+
+func TestWriteDotenv(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	vars := map[string]string{
+		"B": "multi\nline \"quoted\"",
+		"A": "it's $HOME",
+		"C": "p$ss \x1b\"",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(secretlamb.WriteDotenv(&buf, vars))
+	assert.Equal("A=\"it's $HOME\"\nB=\"multi\\nline \\\"quoted\\\"\"\nC='p$ss \x1b\"'\n", buf.String())
+
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(os.WriteFile(path, buf.Bytes(), 0600))
+	src, err := secretlamb.LoadDotenvFile(path)
+	require.NoError(err)
+	assert.Equal(secretlamb.MapSource(vars), src)
+
+	buf.Reset()
+	require.NoError(secretlamb.WriteShellExport(&buf, vars))
+	assert.Equal("export A='it'\\''s $HOME'\nexport B='multi\nline \"quoted\"'\nexport C='p$ss \x1b\"'\n", buf.String())
+}
@@ -0,0 +1,221 @@
+package secretlamb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeParameters is an in-memory ParameterGetter for tests.
+// A name is looked up in Errors, Outputs and Values in that order.
+type FakeParameters struct {
+	Values  map[string]string
+	Outputs map[string]*ParameterOutput
+	Errors  map[string]error
+}
+
+func NewFakeParameters(values map[string]string) *FakeParameters {
+	return &FakeParameters{
+		Values:  values,
+		Outputs: map[string]*ParameterOutput{},
+		Errors:  map[string]error{},
+	}
+}
+
+func (p *FakeParameters) Get(name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return p.GetWithContext(context.Background(), name, options...)
+}
+
+func (p *FakeParameters) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	_, transforms := splitParameterOptions(options)
+	output, err := p.getParameter(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyParameterTransforms(ctx, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter - %w", err)
+	}
+
+	return output, nil
+}
+
+func (p *FakeParameters) getParameter(name string) (*ParameterOutput, error) {
+	if err, ok := p.Errors[name]; ok {
+		return nil, err
+	} else if output, ok := p.Outputs[name]; ok {
+		copied := *output
+		return &copied, nil
+	} else if value, ok := p.Values[name]; ok {
+		output := &ParameterOutput{
+			Parameter: ParameterOutputParameter{
+				Name:     name,
+				Type:     "String",
+				Value:    value,
+				Version:  1,
+				DataType: "text",
+			},
+		}
+
+		return output, nil
+	}
+
+	return nil, fmt.Errorf("failed to get parameter - %w: %s", ErrNotFound, name)
+}
+
+// FakeSecrets is an in-memory SecretGetter for tests.
+// A secret ID is looked up in Errors, Outputs and Values in that order.
+type FakeSecrets struct {
+	Values  map[string]string
+	Outputs map[string]*SecretOutput
+	Errors  map[string]error
+}
+
+func NewFakeSecrets(values map[string]string) *FakeSecrets {
+	return &FakeSecrets{
+		Values:  values,
+		Outputs: map[string]*SecretOutput{},
+		Errors:  map[string]error{},
+	}
+}
+
+func (s *FakeSecrets) Get(secretId string, options ...*SecretOption) (*SecretOutput, error) {
+	return s.GetWithContext(context.Background(), secretId, options)
+}
+
+func (s *FakeSecrets) GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error) {
+	secretId, _, transforms := splitSecretOptions(secretId, options)
+	output, err := s.getSecret(secretId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = applySecretTransforms(ctx, secretId, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret - %w", err)
+	}
+
+	return output, nil
+}
+
+func (s *FakeSecrets) getSecret(secretId string) (*SecretOutput, error) {
+	if err, ok := s.Errors[secretId]; ok {
+		return nil, err
+	} else if output, ok := s.Outputs[secretId]; ok {
+		copied := *output
+		return &copied, nil
+	} else if value, ok := s.Values[secretId]; ok {
+		output := &SecretOutput{
+			Name:          secretId,
+			SecretString:  value,
+			VersionStages: []string{"AWSCURRENT"},
+		}
+
+		return output, nil
+	}
+
+	return nil, fmt.Errorf("failed to get secret - %w: %s", ErrNotFound, secretId)
+}
+
+type ParameterCall struct {
+	Name    string
+	Options []*ParameterOption
+}
+
+// ParameterSpy records every call before delegating to Getter.
+type ParameterSpy struct {
+	Getter ParameterGetter
+	mu     sync.Mutex
+	calls  []ParameterCall
+}
+
+func NewParameterSpy(getter ParameterGetter) *ParameterSpy {
+	return &ParameterSpy{Getter: getter}
+}
+
+func (spy *ParameterSpy) Get(name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return spy.GetWithContext(context.Background(), name, options...)
+}
+
+func (spy *ParameterSpy) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	spy.mu.Lock()
+	spy.calls = append(spy.calls, ParameterCall{Name: name, Options: options})
+	spy.mu.Unlock()
+	return spy.Getter.GetWithContext(ctx, name, options...)
+}
+
+func (spy *ParameterSpy) Calls() []ParameterCall {
+	spy.mu.Lock()
+	defer spy.mu.Unlock()
+	return append([]ParameterCall{}, spy.calls...)
+}
+
+func (spy *ParameterSpy) Names() []string {
+	names := []string{}
+
+	for _, call := range spy.Calls() {
+		names = append(names, call.Name)
+	}
+
+	return names
+}
+
+func (spy *ParameterSpy) Reset() {
+	spy.mu.Lock()
+	defer spy.mu.Unlock()
+	spy.calls = nil
+}
+
+type SecretCall struct {
+	SecretId string
+	Options  []*SecretOption
+}
+
+// SecretSpy records every call before delegating to Getter.
+type SecretSpy struct {
+	Getter SecretGetter
+	mu     sync.Mutex
+	calls  []SecretCall
+}
+
+func NewSecretSpy(getter SecretGetter) *SecretSpy {
+	return &SecretSpy{Getter: getter}
+}
+
+func (spy *SecretSpy) Get(secretId string, options ...*SecretOption) (*SecretOutput, error) {
+	return spy.GetWithContext(context.Background(), secretId, options)
+}
+
+func (spy *SecretSpy) GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error) {
+	spy.mu.Lock()
+	spy.calls = append(spy.calls, SecretCall{SecretId: secretId, Options: options})
+	spy.mu.Unlock()
+	return spy.Getter.GetWithContext(ctx, secretId, options)
+}
+
+func (spy *SecretSpy) Calls() []SecretCall {
+	spy.mu.Lock()
+	defer spy.mu.Unlock()
+	return append([]SecretCall{}, spy.calls...)
+}
+
+func (spy *SecretSpy) SecretIds() []string {
+	secretIds := []string{}
+
+	for _, call := range spy.Calls() {
+		secretIds = append(secretIds, call.SecretId)
+	}
+
+	return secretIds
+}
+
+func (spy *SecretSpy) Reset() {
+	spy.mu.Lock()
+	defer spy.mu.Unlock()
+	spy.calls = nil
+}
@@ -0,0 +1,71 @@
+package secretlamb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func readDatabaseURL(ctx context.Context, p secretlamb.ParameterGetter, s secretlamb.SecretGetter) (string, error) {
+	host, err := p.GetWithContext(ctx, "/app/db/host", secretlamb.ParameterWithDecryption())
+
+	if err != nil {
+		return "", err
+	}
+
+	password, err := s.GetWithContext(ctx, "prod/db", []*secretlamb.SecretOption{secretlamb.SecretVersionStage("AWSCURRENT")})
+
+	if err != nil {
+		return "", err
+	}
+
+	return "postgres://app:" + password.SecretString + "@" + host.Parameter.Value, nil
+}
+
+func TestFakeAndSpy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := secretlamb.NewParameterSpy(secretlamb.NewFakeParameters(map[string]string{"/app/db/host": "db.example.com"}))
+	s := secretlamb.NewSecretSpy(secretlamb.NewFakeSecrets(map[string]string{"prod/db": "p@ss"}))
+
+	url, err := readDatabaseURL(context.Background(), p, s)
+	require.NoError(err)
+	assert.Equal("postgres://app:p@ss@db.example.com", url)
+
+	assert.Equal([]string{"/app/db/host"}, p.Names())
+	assert.Equal([]secretlamb.ParameterCall{{Name: "/app/db/host", Options: []*secretlamb.ParameterOption{secretlamb.ParameterWithDecryption()}}}, p.Calls())
+	assert.Equal([]string{"prod/db"}, s.SecretIds())
+	assert.Equal([]secretlamb.SecretCall{{SecretId: "prod/db", Options: []*secretlamb.SecretOption{secretlamb.SecretVersionStage("AWSCURRENT")}}}, s.Calls())
+
+	p.Reset()
+	s.Reset()
+	assert.Empty(p.Calls())
+	assert.Empty(s.Calls())
+}
+
+func TestFakeErrors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := secretlamb.NewFakeParameters(nil)
+	p.Outputs["/app/db/host"] = &secretlamb.ParameterOutput{Parameter: secretlamb.ParameterOutputParameter{Value: "db.example.com", Version: 3}}
+	s := secretlamb.NewFakeSecrets(map[string]string{"prod/db": "p@ss"})
+	s.Errors["prod/db"] = errors.New("access denied")
+
+	_, err := readDatabaseURL(context.Background(), p, s)
+	assert.EqualError(err, "access denied")
+
+	value, err := p.Get("/app/db/host")
+	require.NoError(err)
+	assert.Equal(int64(3), value.Parameter.Version)
+
+	_, err = p.Get("/app/db/port")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	_, err = secretlamb.NewFakeSecrets(nil).Get("foo")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+}
@@ -0,0 +1,151 @@
+package secretlamb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const DefaultFileMode os.FileMode = 0600
+
+// WriteFileAtomic writes data to a temporary file in the same directory and renames it to path,
+// so readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	err = f.Chmod(mode)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+
+	if err != nil {
+		return err
+	}
+
+	err = f.Sync()
+
+	if err != nil {
+		return err
+	}
+
+	err = f.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// FileWriter writes resolved values to files and rewrites a file only when the version of its ref changes.
+// A zero Mode means DefaultFileMode.
+type FileWriter struct {
+	Resolver *Resolver
+	Mode     os.FileMode
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+func NewFileWriter(r *Resolver) *FileWriter {
+	return &FileWriter{
+		Resolver: r,
+		Mode:     DefaultFileMode,
+		versions: map[string]string{},
+	}
+}
+
+// WriteFiles resolves mapping (path -> ref, e.g. "/tmp/key.pem" -> "secret:prod/tls#key")
+// and returns the paths that were (re)written.
+func (w *FileWriter) WriteFiles(ctx context.Context, mapping map[string]string) ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paths := make([]string, 0, len(mapping))
+
+	for path := range mapping {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	written := []string{}
+	mode := w.Mode
+
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+
+	if w.versions == nil {
+		w.versions = map[string]string{}
+	}
+
+	for _, path := range paths {
+		ref, err := ParseRef(mapping[path])
+
+		if err != nil {
+			return written, fmt.Errorf("failed to write files - %w", err)
+		}
+
+		resolved, err := w.Resolver.Resolve(ctx, ref)
+
+		if err != nil {
+			return written, fmt.Errorf("failed to write files - %s: %w", ref, err)
+		}
+
+		if version, ok := w.versions[path]; ok && version == resolved.Version && fileExists(path) {
+			continue
+		}
+
+		err = WriteFileAtomic(path, []byte(resolved.Value), mode)
+
+		if err != nil {
+			return written, fmt.Errorf("failed to write files - %s: %w", ref, err)
+		}
+
+		w.versions[path] = resolved.Version
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Cleanup removes every file written by w, e.g. on shutdown.
+func (w *FileWriter) Cleanup() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	errs := []error{}
+
+	for path := range w.versions {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+
+		delete(w.versions, path)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to clean up files - %w", err)
+	}
+
+	return nil
+}
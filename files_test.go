@@ -0,0 +1,123 @@
+package secretlamb_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(os.WriteFile(path, []byte("old"), 0644))
+	require.NoError(secretlamb.WriteFileAtomic(path, []byte("new"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Equal("new", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(err)
+	assert.Len(entries, 1)
+}
+
+func TestFileWriter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s := secretlamb.NewFakeSecrets(nil)
+	s.Outputs = map[string]*secretlamb.SecretOutput{
+		"prod/tls": {Name: "prod/tls", VersionID: "v1", SecretString: `{"cert":"CERT1","key":"KEY1"}`},
+	}
+
+	p := secretlamb.NewFakeParameters(map[string]string{"/app/config": "config"})
+	w := secretlamb.NewFileWriter(secretlamb.NewResolver(p, s))
+	w.Mode = 0400
+
+	dir := t.TempDir()
+	mapping := map[string]string{
+		filepath.Join(dir, "cert.pem"):    "secret:prod/tls#cert",
+		filepath.Join(dir, "key.pem"):     "secret:prod/tls#key",
+		filepath.Join(dir, "config.json"): "ssm:/app/config",
+	}
+
+	written, err := w.WriteFiles(context.Background(), mapping)
+	require.NoError(err)
+	assert.Len(written, 3)
+
+	data, err := os.ReadFile(filepath.Join(dir, "key.pem"))
+	require.NoError(err)
+	assert.Equal("KEY1", string(data))
+
+	info, err := os.Stat(filepath.Join(dir, "key.pem"))
+	require.NoError(err)
+	assert.Equal(os.FileMode(0400), info.Mode().Perm())
+
+	// unchanged versions are not rewritten
+	written, err = w.WriteFiles(context.Background(), mapping)
+	require.NoError(err)
+	assert.Empty(written)
+
+	s.Outputs["prod/tls"] = &secretlamb.SecretOutput{Name: "prod/tls", VersionID: "v2", SecretString: `{"cert":"CERT2","key":"KEY2"}`}
+	written, err = w.WriteFiles(context.Background(), mapping)
+	require.NoError(err)
+	assert.Equal([]string{filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")}, written)
+
+	data, err = os.ReadFile(filepath.Join(dir, "cert.pem"))
+	require.NoError(err)
+	assert.Equal("CERT2", string(data))
+
+	// removed files are written again
+	require.NoError(os.Remove(filepath.Join(dir, "config.json")))
+	written, err = w.WriteFiles(context.Background(), mapping)
+	require.NoError(err)
+	assert.Equal([]string{filepath.Join(dir, "config.json")}, written)
+
+	require.NoError(w.Cleanup())
+	entries, err := os.ReadDir(dir)
+	require.NoError(err)
+	assert.Empty(entries)
+}
+
+func TestFileWriterZeroValue(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := secretlamb.NewFakeParameters(map[string]string{"/app/config": "config"})
+	w := &secretlamb.FileWriter{Resolver: secretlamb.NewResolver(p, secretlamb.NewFakeSecrets(nil))}
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	written, err := w.WriteFiles(context.Background(), map[string]string{path: "ssm:/app/config"})
+	require.NoError(err)
+	assert.Equal([]string{path}, written)
+
+	info, err := os.Stat(path)
+	require.NoError(err)
+	assert.Equal(secretlamb.DefaultFileMode, info.Mode().Perm())
+
+	require.NoError(w.Cleanup())
+	assert.NoFileExists(path)
+	assert.NoError((&secretlamb.FileWriter{}).Cleanup())
+}
+
+func TestFileWriterErr(t *testing.T) {
+	assert := assert.New(t)
+
+	w := secretlamb.NewFileWriter(secretlamb.NewResolver(secretlamb.NewFakeParameters(nil), secretlamb.NewFakeSecrets(nil)))
+	_, err := w.WriteFiles(context.Background(), map[string]string{filepath.Join(t.TempDir(), "x"): "ssm:/missing"})
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+
+	_, err = w.WriteFiles(context.Background(), map[string]string{filepath.Join(t.TempDir(), "x"): "/missing"})
+	assert.ErrorContains(err, "failed to write files - invalid reference")
+}
@@ -0,0 +1,80 @@
+package secretlamb
+
+import (
+	"context"
+	"encoding"
+	"flag"
+	"fmt"
+)
+
+// RefValue resolves a ref such as "secret:prod/db#password" when it is set by flag parsing or text decoding
+// (encoding/json, env-decoding libraries, ...). Strings that are not refs are used as is.
+// Resolver defaults to DefaultResolver.
+// String, GoString and MarshalText have value receivers, so a RefValue held by value never reveals Value.
+type RefValue struct {
+	Value    string `json:"-"`
+	Raw      string
+	Resolver *Resolver `json:"-"`
+}
+
+var (
+	_ flag.Value               = (*RefValue)(nil)
+	_ encoding.TextUnmarshaler = (*RefValue)(nil)
+	_ encoding.TextMarshaler   = RefValue{}
+	_ fmt.Stringer             = RefValue{}
+	_ fmt.GoStringer           = RefValue{}
+)
+
+// RefVar defines a flag resolved through r (nil means DefaultResolver), e.g. -db-password=secret:prod/db#password.
+func RefVar(fs *flag.FlagSet, name string, r *Resolver, usage string) *RefValue {
+	v := &RefValue{Resolver: r}
+	fs.Var(v, name, usage)
+	return v
+}
+
+func (v *RefValue) Set(s string) error {
+	v.Raw = s
+	ref, err := ParseRef(s)
+
+	if err != nil {
+		v.Value = s
+		return nil
+	}
+
+	r := v.Resolver
+
+	if r == nil {
+		r, err = defaultResolver()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	resolved, err := r.Resolve(context.Background(), ref)
+
+	if err != nil {
+		return err
+	}
+
+	v.Value = resolved.Value
+	return nil
+}
+
+// String returns the ref rather than the resolved value, so flag usage and logs do not reveal secrets.
+func (v RefValue) String() string {
+	return v.Raw
+}
+
+func (v RefValue) GoString() string {
+	return fmt.Sprintf("secretlamb.RefValue{Raw:%q}", v.Raw)
+}
+
+func (v *RefValue) UnmarshalText(text []byte) error {
+	return v.Set(string(text))
+}
+
+// MarshalText returns the ref, not the resolved value.
+func (v RefValue) MarshalText() ([]byte, error) {
+	return []byte(v.Raw), nil
+}
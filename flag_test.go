@@ -0,0 +1,86 @@
+package secretlamb_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func newFlagTestResolver() *secretlamb.Resolver {
+	return secretlamb.NewResolver(
+		secretlamb.NewFakeParameters(map[string]string{"/app/host": "db.example.com"}),
+		secretlamb.NewFakeSecrets(map[string]string{"prod/db": `{"password":"tiger"}`}),
+	)
+}
+
+func TestRefVar(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	r := newFlagTestResolver()
+	password := secretlamb.RefVar(fs, "db-password", r, "database password")
+	host := secretlamb.RefVar(fs, "db-host", r, "database host")
+	user := secretlamb.RefVar(fs, "db-user", r, "database user")
+
+	err := fs.Parse([]string{"-db-password=secret:prod/db#password", "-db-host", "ssm:/app/host", "-db-user=scott"})
+	require.NoError(err)
+	assert.Equal("tiger", password.Value)
+	assert.Equal("secret:prod/db#password", password.String())
+	assert.Equal("db.example.com", host.Value)
+	assert.Equal("scott", user.Value)
+
+	err = fs.Parse([]string{"-db-host=ssm:/missing"})
+	assert.ErrorContains(err, `invalid value "ssm:/missing" for flag -db-host: failed to get parameter - not found: /missing`)
+}
+
+func TestRefValueUnmarshalJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	secretlamb.DefaultResolver = newFlagTestResolver()
+	defer func() { secretlamb.DefaultResolver = nil }()
+
+	var config struct {
+		Host     secretlamb.RefValue `json:"host"`
+		Password secretlamb.RefValue `json:"password"`
+	}
+
+	err := json.Unmarshal([]byte(`{"host":"ssm:/app/host","password":"secret:prod/db#password"}`), &config)
+	require.NoError(err)
+	assert.Equal("db.example.com", config.Host.Value)
+	assert.Equal("tiger", config.Password.Value)
+
+	out, err := json.Marshal(&config)
+	require.NoError(err)
+	assert.JSONEq(`{"host":"ssm:/app/host","password":"secret:prod/db#password"}`, string(out))
+}
+
+func TestRefValueByValue(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	password := secretlamb.RefValue{Resolver: newFlagTestResolver()}
+	require.NoError(password.Set("secret:prod/db#password"))
+	require.Equal("tiger", password.Value)
+
+	config := struct {
+		Password secretlamb.RefValue `json:"password"`
+	}{Password: password}
+
+	out, err := json.Marshal(config)
+	require.NoError(err)
+	assert.JSONEq(`{"password":"secret:prod/db#password"}`, string(out))
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		assert.NotContains(fmt.Sprintf(format, config), "tiger", format)
+		assert.NotContains(fmt.Sprintf(format, password), "tiger", format)
+	}
+}
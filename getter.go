@@ -0,0 +1,20 @@
+package secretlamb
+
+import (
+	"context"
+)
+
+type ParameterGetter interface {
+	Get(name string, options ...*ParameterOption) (*ParameterOutput, error)
+	GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error)
+}
+
+type SecretGetter interface {
+	Get(secretId string, options ...*SecretOption) (*SecretOutput, error)
+	GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error)
+}
+
+var (
+	_ ParameterGetter = (*Parameters)(nil)
+	_ SecretGetter    = (*Secrets)(nil)
+)
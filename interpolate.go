@@ -0,0 +1,168 @@
+package secretlamb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxInterpolationDepth limits how deeply referenced values are expanded.
+const DefaultMaxInterpolationDepth = 8
+
+var (
+	ErrInterpolationCycle = errors.New("reference cycle")
+	ErrInterpolationDepth = errors.New("max interpolation depth exceeded")
+	ErrInvalidPlaceholder = errors.New("invalid placeholder")
+)
+
+// InterpolationError reports the chain of references that led to the failure,
+// starting from the outermost placeholder. Errors never include the text of resolved values,
+// so an invalid placeholder is reported by its offset in the value that contains it.
+type InterpolationError struct {
+	Chain []Ref
+	Err   error
+}
+
+func (e *InterpolationError) Error() string {
+	if len(e.Chain) == 0 {
+		return fmt.Sprintf("failed to interpolate: %s", e.Err)
+	}
+
+	chain := make([]string, len(e.Chain))
+
+	for i, ref := range e.Chain {
+		chain[i] = ref.String()
+	}
+
+	return fmt.Sprintf("failed to interpolate %s: %s", strings.Join(chain, " -> "), e.Err)
+}
+
+func (e *InterpolationError) Unwrap() error {
+	return e.Err
+}
+
+// Interpolator expands ${ssm:/name} and ${secret:id#key} placeholders.
+// Referenced values are expanded recursively, and "$${" is an escaped literal "${".
+type Interpolator struct {
+	Resolver *Resolver
+	MaxDepth int
+}
+
+func NewInterpolator(r *Resolver) *Interpolator {
+	return &Interpolator{Resolver: r, MaxDepth: DefaultMaxInterpolationDepth}
+}
+
+func (i *Interpolator) Expand(ctx context.Context, s string) (string, error) {
+	memo := map[Ref]string{}
+	return i.expand(ctx, s, nil, memo)
+}
+
+func (i *Interpolator) expand(ctx context.Context, s string, chain []Ref, memo map[Ref]string) (string, error) {
+	var b strings.Builder
+	offset := 0
+
+	for {
+		start := strings.Index(s, "${")
+
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1] + "${")
+			s = s[start+2:]
+			offset += start + 2
+			continue
+		}
+
+		b.WriteString(s[:start])
+		end := strings.IndexByte(s[start:], '}')
+
+		if end < 0 {
+			return "", &InterpolationError{Chain: chain, Err: fmt.Errorf("%w: unterminated at offset %d", ErrInvalidPlaceholder, offset+start)}
+		}
+
+		value, err := i.expandRef(ctx, s[start+2:start+end], offset+start, chain, memo)
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(value)
+		s = s[start+end+1:]
+		offset += start + end + 1
+	}
+}
+
+func (i *Interpolator) expandRef(ctx context.Context, placeholder string, offset int, chain []Ref, memo map[Ref]string) (string, error) {
+	ref, err := ParseRef(placeholder)
+
+	if err != nil {
+		// The error of ParseRef quotes the placeholder, which may be part of a secret.
+		return "", &InterpolationError{Chain: chain, Err: fmt.Errorf("%w at offset %d", ErrInvalidPlaceholder, offset)}
+	}
+
+	refChain := append(chain[:len(chain):len(chain)], ref)
+
+	if slices.Contains(chain, ref) {
+		return "", &InterpolationError{Chain: refChain, Err: ErrInterpolationCycle}
+	} else if len(refChain) > i.MaxDepth {
+		return "", &InterpolationError{Chain: refChain, Err: ErrInterpolationDepth}
+	}
+
+	if value, ok := memo[ref]; ok {
+		return value, nil
+	}
+
+	resolved, err := i.Resolver.Resolve(ctx, ref)
+
+	if err != nil {
+		return "", &InterpolationError{Chain: refChain, Err: err}
+	}
+
+	value, err := i.expand(ctx, resolved.Value, refChain, memo)
+
+	if err != nil {
+		return "", err
+	}
+
+	memo[ref] = value
+	return value, nil
+}
+
+// ParameterInterpolate expands placeholders in the value with i.
+func ParameterInterpolate(i *Interpolator) *ParameterOption {
+	return &ParameterOption{
+		transform: func(ctx context.Context, output *ParameterOutput) error {
+			chain := []Ref{{Service: RefServiceParameter, Name: output.Parameter.Name}}
+			value, err := i.expand(ctx, output.Parameter.Value, chain, map[Ref]string{})
+
+			if err != nil {
+				return err
+			}
+
+			output.Parameter.Value = value
+			return nil
+		},
+	}
+}
+
+// SecretInterpolate expands placeholders in the secret string with i.
+func SecretInterpolate(i *Interpolator) *SecretOption {
+	return &SecretOption{
+		transform: func(ctx context.Context, output *SecretOutput) error {
+			chain := []Ref{{Service: RefServiceSecret, Name: output.Name}}
+			value, err := i.expand(ctx, output.SecretString, chain, map[Ref]string{})
+
+			if err != nil {
+				return err
+			}
+
+			output.SecretString = value
+			return nil
+		},
+	}
+}
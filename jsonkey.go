@@ -0,0 +1,198 @@
+package secretlamb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SecretKeySeparator separates a secret ID and a JSON key path, as in "prod/db#password".
+// Secret names cannot contain "#".
+const SecretKeySeparator = "#"
+
+// JSONKeyError never includes the secret payload.
+type JSONKeyError struct {
+	SecretId string
+	Path     string
+	Reason   string
+}
+
+func (e *JSONKeyError) Error() string {
+	return fmt.Sprintf("cannot select %q from secret %s: %s", e.Path, e.SecretId, e.Reason)
+}
+
+// SecretJSONKey replaces SecretString with the value at path, e.g. "credentials.password" or "hosts[0].name".
+// A JSON string is returned unquoted, other values as raw JSON.
+func SecretJSONKey(path string) *SecretOption {
+	return &SecretOption{
+		transform: func(ctx context.Context, output *SecretOutput) error {
+			value, err := selectJSONKey(output.SecretString, path)
+
+			if err != nil {
+				err.SecretId = output.Name
+				return err
+			}
+
+			output.SecretString = value
+			return nil
+		},
+	}
+}
+
+type jsonPathSegment struct {
+	key   string
+	index int
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	segments := []jsonPathSegment{}
+
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+
+		if key != "" {
+			segments = append(segments, jsonPathSegment{key: key, index: -1})
+		} else if rest == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+
+			if !ok {
+				return nil, fmt.Errorf("missing ']'")
+			}
+
+			i, err := strconv.Atoi(idx)
+
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index %q", idx)
+			}
+
+			segments = append(segments, jsonPathSegment{index: i})
+
+			if after == "" {
+				break
+			} else if !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("unexpected %q after index", after)
+			}
+
+			rest = after[1:]
+		}
+	}
+
+	return segments, nil
+}
+
+func selectJSONKey(payload string, path string) (string, *JSONKeyError) {
+	segments, err := parseJSONPath(path)
+
+	if err != nil {
+		return "", &JSONKeyError{Path: path, Reason: "invalid path: " + err.Error()}
+	}
+
+	current := json.RawMessage(payload)
+
+	for i, seg := range segments {
+		if seg.index < 0 {
+			object := map[string]json.RawMessage{}
+
+			if json.Unmarshal(current, &object) != nil {
+				return "", &JSONKeyError{Path: path, Reason: "not a JSON object at " + jsonPathPrefix(segments[:i])}
+			}
+
+			value, ok := object[seg.key]
+
+			if !ok {
+				return "", &JSONKeyError{Path: path, Reason: "key not found"}
+			}
+
+			current = value
+		} else {
+			array := []json.RawMessage{}
+
+			if json.Unmarshal(current, &array) != nil {
+				return "", &JSONKeyError{Path: path, Reason: "not a JSON array at " + jsonPathPrefix(segments[:i])}
+			}
+
+			if seg.index >= len(array) {
+				return "", &JSONKeyError{Path: path, Reason: "index out of range"}
+			}
+
+			current = array[seg.index]
+		}
+	}
+
+	// null would otherwise be unmarshaled as an empty string.
+	if bytes.Equal(bytes.TrimSpace(current), []byte("null")) {
+		return "", &JSONKeyError{Path: path, Reason: "null value"}
+	}
+
+	var s string
+
+	if json.Unmarshal(current, &s) == nil {
+		return s, nil
+	}
+
+	var compacted bytes.Buffer
+
+	if json.Compact(&compacted, current) != nil {
+		return "", &JSONKeyError{Path: path, Reason: "invalid JSON"}
+	}
+
+	return compacted.String(), nil
+}
+
+func jsonPathPrefix(segments []jsonPathSegment) string {
+	var b strings.Builder
+	b.WriteString("$")
+
+	for _, seg := range segments {
+		if seg.index < 0 {
+			b.WriteString("." + seg.key)
+		} else {
+			b.WriteString("[" + strconv.Itoa(seg.index) + "]")
+		}
+	}
+
+	return b.String()
+}
+
+// splitSecretOptions separates the "#key" selector of secretId and the options
+// that are applied to the output instead of being sent to the extension.
+func splitSecretOptions(secretId string, options []*SecretOption) (string, []*SecretOption, []*SecretOption) {
+	query := []*SecretOption{}
+	transforms := []*SecretOption{}
+
+	for _, opt := range options {
+		if opt.transform != nil {
+			transforms = append(transforms, opt)
+		} else {
+			query = append(query, opt)
+		}
+	}
+
+	if id, key, ok := strings.Cut(secretId, SecretKeySeparator); ok {
+		secretId = id
+		transforms = append(transforms, SecretJSONKey(key))
+	}
+
+	return secretId, query, transforms
+}
+
+func applySecretTransforms(ctx context.Context, secretId string, output *SecretOutput, transforms []*SecretOption) error {
+	for _, opt := range transforms {
+		if err := opt.transform(ctx, output); err != nil {
+			if keyErr, ok := err.(*JSONKeyError); ok && keyErr.SecretId == "" {
+				keyErr.SecretId = secretId
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package secretlamb_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+const jsonKeySecret = `{"username":"scott","password":"tiger","port":5432,"credentials":{"password":"nested"},"hosts":[{"name":"db1"},{"name":"db2"}],"token":null}`
+
+func TestSecretsGetJSONKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", jsonKeySecret)))
+
+	s := secretlamb.MustNewSecrets()
+
+	tests := map[string]string{
+		"password":             "tiger",
+		"port":                 "5432",
+		"credentials.password": "nested",
+		"credentials":          `{"password":"nested"}`,
+		"hosts[1].name":        "db2",
+	}
+
+	for key, expected := range tests {
+		output, err := s.Get("prod/db#" + key)
+		require.NoError(err, key)
+		assert.Equal(expected, output.SecretString, key)
+		assert.Equal("v1", output.VersionID)
+
+		output, err = s.Get("prod/db", secretlamb.SecretJSONKey(key))
+		require.NoError(err, key)
+		assert.Equal(expected, output.SecretString, key)
+	}
+}
+
+func TestSecretsGetJSONKeyWithVersion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb&versionStage=AWSPREVIOUS", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v0", jsonKeySecret)))
+
+	s := secretlamb.MustNewSecrets()
+	output, err := s.Get("prod/db#username", secretlamb.SecretVersionStage("AWSPREVIOUS"))
+	require.NoError(err)
+	assert.Equal("scott", output.SecretString)
+}
+
+func TestSecretsGetJSONKeyErr(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", jsonKeySecret)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=plain", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "s3cr3t")))
+
+	s := secretlamb.MustNewSecrets()
+
+	tests := map[string]string{
+		"prod/db#missing":        "key not found",
+		"prod/db#hosts[5]":       "index out of range",
+		"prod/db#password.value": "not a JSON object at $.password",
+		"prod/db#hosts[x]":       "invalid path",
+		"plain#password":         "not a JSON object at $",
+		"prod/db#token":          "null value",
+	}
+
+	for id, reason := range tests {
+		_, err := s.Get(id)
+		var keyErr *secretlamb.JSONKeyError
+		if assert.ErrorAs(err, &keyErr, id) {
+			assert.Equal(reason, keyErr.Reason[:len(reason)], id)
+			assert.Equal("MyTestSecret", keyErr.SecretId)
+		}
+		assert.NotContains(err.Error(), "tiger")
+		assert.NotContains(err.Error(), "s3cr3t")
+	}
+}
+
+func TestLocalAndFakeSecretsJSONKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	local := secretlamb.NewLocalSecrets(secretlamb.MapSource{"prod/db": jsonKeySecret})
+	output, err := local.Get("prod/db#hosts[0].name")
+	require.NoError(err)
+	assert.Equal("db1", output.SecretString)
+
+	fake := secretlamb.NewFakeSecrets(map[string]string{"prod/db": jsonKeySecret})
+	output, err = fake.Get("prod/db#credentials.password")
+	require.NoError(err)
+	assert.Equal("nested", output.SecretString)
+
+	_, err = fake.Get("prod/db#nope")
+	assert.ErrorContains(err, `cannot select "nope" from secret prod/db: key not found`)
+}
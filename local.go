@@ -0,0 +1,324 @@
+package secretlamb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ProviderEnv = "SECRETLAMB_PROVIDER"
+
+const (
+	ProviderExtension = "extension"
+	ProviderLocal     = "local"
+)
+
+// InLambda reports whether the extension should be used.
+// SECRETLAMB_PROVIDER=extension|local overrides the detection by AWS_LAMBDA_FUNCTION_NAME/AWS_LAMBDA_RUNTIME_API.
+func InLambda() bool {
+	switch os.Getenv(ProviderEnv) {
+	case ProviderExtension:
+		return true
+	case ProviderLocal:
+		return false
+	}
+
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" || os.Getenv("AWS_LAMBDA_RUNTIME_API") != ""
+}
+
+func NewParameterGetter(local LocalSource) (ParameterGetter, error) {
+	if InLambda() {
+		return NewParameters()
+	}
+
+	return NewLocalParameters(local), nil
+}
+
+func NewSecretGetter(local LocalSource) (SecretGetter, error) {
+	if InLambda() {
+		return NewSecrets()
+	}
+
+	return NewLocalSecrets(local), nil
+}
+
+type LocalSource interface {
+	Lookup(name string) (value string, ok bool, err error)
+}
+
+// MapSource looks up a name as is, then as an environment variable style key (e.g. "/app/db-host" -> "APP_DB_HOST").
+type MapSource map[string]string
+
+func (src MapSource) Lookup(name string) (string, bool, error) {
+	if value, ok := src[name]; ok {
+		return value, true, nil
+	}
+
+	value, ok := src[envKey(name)]
+	return value, ok, nil
+}
+
+type EnvSource struct {
+	Prefix string
+}
+
+func (src *EnvSource) Lookup(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(src.Prefix + envKey(name))
+	return value, ok, nil
+}
+
+// DirSource reads the value of a name from the file of the same relative path under Dir.
+type DirSource struct {
+	Dir string
+}
+
+func (src *DirSource) Lookup(name string) (string, bool, error) {
+	rel := filepath.FromSlash(strings.TrimPrefix(name, "/"))
+
+	if !filepath.IsLocal(rel) {
+		return "", false, fmt.Errorf("invalid name %q for directory source", name)
+	}
+
+	value, err := os.ReadFile(filepath.Join(src.Dir, rel))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(string(value), "\n"), "\r"), true, nil
+}
+
+func envKey(name string) string {
+	key := strings.Map(func(r rune) rune {
+		if ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		} else if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+
+		return '_'
+	}, name)
+
+	return strings.TrimLeft(key, "_")
+}
+
+// LoadFile loads a JSON (.json), YAML (.yaml, .yml) or dotenv (any other extension) file.
+func LoadFile(path string) (MapSource, error) {
+	switch filepath.Ext(path) {
+	case ".json":
+		return LoadJSONFile(path)
+	case ".yaml", ".yml":
+		return LoadYAMLFile(path)
+	default:
+		return LoadDotenvFile(path)
+	}
+}
+
+// LoadJSONFile loads a JSON object of names to values. Non-string values are stored as JSON.
+func LoadJSONFile(path string) (MapSource, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JSON file - %w", err)
+	}
+
+	values := map[string]any{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to load JSON file - json unmarshal error: %w", err)
+	}
+
+	src, err := newMapSource(values)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JSON file - %w", err)
+	}
+
+	return src, nil
+}
+
+// LoadYAMLFile loads a YAML mapping of names to values. Non-string values are stored as JSON.
+func LoadYAMLFile(path string) (MapSource, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load YAML file - %w", err)
+	}
+
+	values := map[string]any{}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to load YAML file - yaml unmarshal error: %w", err)
+	}
+
+	src, err := newMapSource(values)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load YAML file - %w", err)
+	}
+
+	return src, nil
+}
+
+func newMapSource(values map[string]any) (MapSource, error) {
+	src := MapSource{}
+
+	for name, value := range values {
+		if s, ok := value.(string); ok {
+			src[name] = s
+			continue
+		}
+
+		data, err := json.Marshal(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("json marshal error: %s: %w", name, err)
+		}
+
+		src[name] = string(data)
+	}
+
+	return src, nil
+}
+
+func LoadDotenvFile(path string) (MapSource, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dotenv file - %w", err)
+	}
+
+	defer f.Close()
+	src := MapSource{}
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+
+		if !ok {
+			return nil, fmt.Errorf("failed to load dotenv file - %s:%d: missing '='", path, lineno)
+		}
+
+		value, err = unquoteDotenv(strings.TrimSpace(value))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dotenv file - %s:%d: %w", path, lineno, err)
+		}
+
+		src[strings.TrimSpace(key)] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load dotenv file - %w", err)
+	}
+
+	return src, nil
+}
+
+func unquoteDotenv(value string) (string, error) {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1], nil
+	} else if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return strconv.Unquote(value)
+	}
+
+	return value, nil
+}
+
+type LocalParameters struct {
+	Source LocalSource
+}
+
+func NewLocalParameters(src LocalSource) *LocalParameters {
+	return &LocalParameters{Source: src}
+}
+
+func (p *LocalParameters) Get(name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	return p.GetWithContext(context.Background(), name, options...)
+}
+
+// GetWithContext ignores version, label and decryption options.
+func (p *LocalParameters) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	_, transforms := splitParameterOptions(options)
+	value, ok, err := p.Source.Lookup(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter - local source error: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("failed to get parameter - %w: %s", ErrNotFound, name)
+	}
+
+	output := &ParameterOutput{
+		Parameter: ParameterOutputParameter{
+			Name:     name,
+			Type:     "String",
+			Value:    value,
+			Version:  1,
+			DataType: "text",
+		},
+	}
+
+	err = applyParameterTransforms(ctx, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter - %w", err)
+	}
+
+	return output, nil
+}
+
+type LocalSecrets struct {
+	Source LocalSource
+}
+
+func NewLocalSecrets(src LocalSource) *LocalSecrets {
+	return &LocalSecrets{Source: src}
+}
+
+func (s *LocalSecrets) Get(secretId string, options ...*SecretOption) (*SecretOutput, error) {
+	return s.GetWithContext(context.Background(), secretId, options)
+}
+
+// GetWithContext ignores version options.
+func (s *LocalSecrets) GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error) {
+	secretId, _, transforms := splitSecretOptions(secretId, options)
+	value, ok, err := s.Source.Lookup(secretId)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret - local source error: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("failed to get secret - %w: %s", ErrNotFound, secretId)
+	}
+
+	output := &SecretOutput{
+		Name:          secretId,
+		SecretString:  value,
+		VersionStages: []string{"AWSCURRENT"},
+	}
+
+	err = applySecretTransforms(ctx, secretId, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret - %w", err)
+	}
+
+	return output, nil
+}
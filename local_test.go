@@ -0,0 +1,145 @@
+package secretlamb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestInLambda(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "")
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	t.Setenv("SECRETLAMB_PROVIDER", "")
+	assert.False(secretlamb.InLambda())
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "my-function")
+	assert.True(secretlamb.InLambda())
+
+	t.Setenv("SECRETLAMB_PROVIDER", "local")
+	assert.False(secretlamb.InLambda())
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "")
+	t.Setenv("SECRETLAMB_PROVIDER", "extension")
+	assert.True(secretlamb.InLambda())
+}
+
+func TestNewParameterGetter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "")
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	t.Setenv("SECRETLAMB_PROVIDER", "")
+	p, err := secretlamb.NewParameterGetter(secretlamb.MapSource{"/app/host": "localhost"})
+	require.NoError(err)
+	assert.IsType(&secretlamb.LocalParameters{}, p)
+
+	value, err := p.Get("/app/host")
+	require.NoError(err)
+	assert.Equal("localhost", value.Parameter.Value)
+
+	t.Setenv("SECRETLAMB_PROVIDER", "extension")
+	p, err = secretlamb.NewParameterGetter(nil)
+	require.NoError(err)
+	assert.IsType(&secretlamb.Parameters{}, p)
+
+	s, err := secretlamb.NewSecretGetter(nil)
+	require.NoError(err)
+	assert.IsType(&secretlamb.Secrets{}, s)
+}
+
+func TestLocalSecretsNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	s := secretlamb.NewLocalSecrets(secretlamb.MapSource{})
+	_, err := s.Get("prod/db")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.ErrorContains(err, "failed to get secret - not found: prod/db")
+}
+
+func TestEnvSource(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	t.Setenv("LOCAL_APP_DB_HOST", "localhost")
+
+	p := secretlamb.NewLocalParameters(&secretlamb.EnvSource{Prefix: "LOCAL_"})
+	value, err := p.Get("/app/db-host")
+	require.NoError(err)
+
+	assert.Equal(
+		&secretlamb.ParameterOutput{
+			Parameter: secretlamb.ParameterOutputParameter{
+				Name:     "/app/db-host",
+				Type:     "String",
+				Value:    "localhost",
+				Version:  1,
+				DataType: "text",
+			},
+		},
+		value,
+	)
+}
+
+func TestDirSource(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	dir := t.TempDir()
+	require.NoError(os.MkdirAll(filepath.Join(dir, "prod"), 0o700))
+	require.NoError(os.WriteFile(filepath.Join(dir, "prod", "db"), []byte("{\"password\":\"p@ss\"}\n"), 0o600))
+
+	s := secretlamb.NewLocalSecrets(&secretlamb.DirSource{Dir: dir})
+	value, err := s.Get("prod/db")
+	require.NoError(err)
+
+	assert.Equal(
+		&secretlamb.SecretOutput{
+			Name:          "prod/db",
+			SecretString:  `{"password":"p@ss"}`,
+			VersionStages: []string{"AWSCURRENT"},
+		},
+		value,
+	)
+
+	_, err = s.Get("../etc/passwd")
+	assert.ErrorContains(err, "failed to get secret - local source error: invalid name")
+}
+
+func TestLoadFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "secrets.json")
+	require.NoError(os.WriteFile(jsonPath, []byte(`{"prod/db": {"password": "p@ss"}, "/app/host": "localhost"}`), 0o600))
+	yamlPath := filepath.Join(dir, "secrets.yml")
+	require.NoError(os.WriteFile(yamlPath, []byte("prod/db:\n  password: p@ss\n/app/host: localhost\n"), 0o600))
+	dotenvPath := filepath.Join(dir, ".env")
+	require.NoError(os.WriteFile(dotenvPath, []byte("# comment\nexport PROD_DB='{\"password\":\"p@ss\"}'\nAPP_HOST=\"local\\thost\"\n"), 0o600))
+
+	src, err := secretlamb.LoadFile(jsonPath)
+	require.NoError(err)
+	assert.Equal(secretlamb.MapSource{"prod/db": `{"password":"p@ss"}`, "/app/host": "localhost"}, src)
+
+	src, err = secretlamb.LoadFile(yamlPath)
+	require.NoError(err)
+	assert.Equal(secretlamb.MapSource{"prod/db": `{"password":"p@ss"}`, "/app/host": "localhost"}, src)
+
+	src, err = secretlamb.LoadFile(dotenvPath)
+	require.NoError(err)
+	assert.Equal(secretlamb.MapSource{"PROD_DB": `{"password":"p@ss"}`, "APP_HOST": "local\thost"}, src)
+
+	value, ok, err := src.Lookup("prod/db")
+	require.NoError(err)
+	assert.True(ok)
+	assert.Equal(`{"password":"p@ss"}`, value)
+
+	require.NoError(os.WriteFile(dotenvPath, []byte("FOO\n"), 0o600))
+	_, err = secretlamb.LoadDotenvFile(dotenvPath)
+	assert.ErrorContains(err, ".env:1: missing '='")
+}
@@ -0,0 +1,181 @@
+package secretlamb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultManifestFile = "secretlamb.yaml"
+	DefaultLockfile     = "secretlamb.lock.json"
+)
+
+// Manifest lists the refs a function uses:
+//
+//	refs:
+//	  - ssm:/app/db/host
+//	  - secret:prod/db
+type Manifest struct {
+	Refs []string `yaml:"refs"`
+}
+
+func LoadManifest(path string) ([]Ref, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest - %w", err)
+	}
+
+	manifest := &Manifest{}
+	err = yaml.Unmarshal(data, manifest)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest - yaml unmarshal error: %w", err)
+	}
+
+	refs := []Ref{}
+
+	for _, s := range manifest.Refs {
+		ref, err := ParseRef(s)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load manifest - %w", err)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// Lockfile pins parameter versions and secret version IDs.
+// Secrets are keyed by secret ID without a "#key" selector.
+// A key also pins requests by the ARN of the same parameter/secret once the caller's account is known,
+// and a secret ARN key pins the other form of the ARN once a response has confirmed the random suffix.
+type Lockfile struct {
+	Parameters map[string]int64  `json:"parameters"`
+	Secrets    map[string]string `json:"secrets"`
+}
+
+// Lock records the current versions of refs.
+func (r *Resolver) Lock(ctx context.Context, refs []Ref) (*Lockfile, error) {
+	lockfile := &Lockfile{
+		Parameters: map[string]int64{},
+		Secrets:    map[string]string{},
+	}
+
+	for _, ref := range refs {
+		resolved, err := r.Resolve(ctx, ref)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock - %s: %w", ref, err)
+		}
+
+		switch ref.Service {
+		case RefServiceParameter:
+			version, err := strconv.ParseInt(resolved.Version, 10, 64)
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to lock - %s: invalid version %q", ref, resolved.Version)
+			}
+
+			lockfile.Parameters[ref.Name] = version
+		case RefServiceSecret:
+			secretId, _, _ := strings.Cut(ref.Name, SecretKeySeparator)
+			lockfile.Secrets[secretId] = resolved.Version
+		}
+	}
+
+	return lockfile, nil
+}
+
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lockfile - %w", err)
+	}
+
+	lockfile := &Lockfile{}
+	err = json.Unmarshal(data, lockfile)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lockfile - json unmarshal error: %w", err)
+	}
+
+	return lockfile, nil
+}
+
+func (l *Lockfile) WriteFile(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("failed to write lockfile - json marshal error: %w", err)
+	}
+
+	err = WriteFileAtomic(path, append(data, '\n'), 0644)
+
+	if err != nil {
+		return fmt.Errorf("failed to write lockfile - %w", err)
+	}
+
+	return nil
+}
+
+// parameterOptions adds the locked version unless a version or label is already given.
+func (l *Lockfile) parameterOptions(name string, canonical func(string) string, options []*ParameterOption) []*ParameterOption {
+	version, ok := lookupLocked(l.Parameters, name, canonical)
+
+	if !ok {
+		return options
+	}
+
+	for _, opt := range options {
+		if opt.Key == "version" || opt.Key == "label" {
+			return options
+		}
+	}
+
+	return append(options, ParameterVersion(int(version)))
+}
+
+// secretOptions adds the locked version ID unless a version ID or stage is already given.
+func (l *Lockfile) secretOptions(secretId string, canonical func(string) string, options []*SecretOption) []*SecretOption {
+	versionId, ok := lookupLocked(l.Secrets, secretId, canonical)
+
+	if !ok {
+		return options
+	}
+
+	for _, opt := range options {
+		if opt.Key == "versionId" || opt.Key == "versionStage" {
+			return options
+		}
+	}
+
+	return append(options, SecretVersionId(versionId))
+}
+
+// lookupLocked finds name, or a key for the same parameter/secret such as its name or another form of its ARN.
+func lookupLocked[V any](locked map[string]V, name string, canonical func(string) string) (V, bool) {
+	if v, ok := locked[name]; ok {
+		return v, true
+	}
+
+	name = canonical(name)
+
+	for key, v := range locked {
+		if canonical(key) == name {
+			return v, true
+		}
+	}
+
+	var zero V
+	return zero, false
+}
@@ -0,0 +1,187 @@
+package secretlamb_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestLock(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "secretlamb.yaml")
+	require.NoError(os.WriteFile(manifestPath, []byte("refs:\n  - ssm:/app/host\n  - secret:prod/db#password\n"), 0644))
+
+	refs, err := secretlamb.LoadManifest(manifestPath)
+	require.NoError(err)
+
+	p := secretlamb.NewFakeParameters(nil)
+	p.Outputs = map[string]*secretlamb.ParameterOutput{
+		"/app/host": {Parameter: secretlamb.ParameterOutputParameter{Name: "/app/host", Value: "db.example.com", Version: 7}},
+	}
+
+	s := secretlamb.NewFakeSecrets(nil)
+	s.Outputs = map[string]*secretlamb.SecretOutput{
+		"prod/db": {Name: "prod/db", VersionID: "EXAMPLE1-90ab-cdef-fedc-ba987SECRET1", SecretString: `{"password":"tiger"}`},
+	}
+
+	lockfile, err := secretlamb.NewResolver(p, s).Lock(context.Background(), refs)
+	require.NoError(err)
+
+	lockPath := filepath.Join(dir, "secretlamb.lock.json")
+	require.NoError(lockfile.WriteFile(lockPath))
+
+	data, err := os.ReadFile(lockPath)
+	require.NoError(err)
+	assert.JSONEq(`{"parameters":{"/app/host":7},"secrets":{"prod/db":"EXAMPLE1-90ab-cdef-fedc-ba987SECRET1"}}`, string(data))
+
+	loaded, err := secretlamb.LoadLockfile(lockPath)
+	require.NoError(err)
+	assert.Equal(lockfile, loaded)
+}
+
+func TestLoadManifestErr(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "secretlamb.yaml")
+	os.WriteFile(path, []byte("refs:\n  - /app/host\n"), 0644)
+
+	_, err := secretlamb.LoadManifest(path)
+	assert.ErrorContains(err, "failed to load manifest - invalid reference")
+}
+
+func TestWithLockfile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fhost&version=7", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 7, "locked")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?label=beta&name=%2Fapp%2Fhost", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 8, "beta")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fother", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "unlocked")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb&versionId=v1", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", `{"password":"tiger"}`)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb&versionStage=AWSPENDING", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v2", `{"password":"lion"}`)))
+
+	lockfile := &secretlamb.Lockfile{
+		Parameters: map[string]int64{"/app/host": 7},
+		Secrets:    map[string]string{"prod/db": "v1"},
+	}
+
+	p := secretlamb.MustNewParameters().WithLockfile(lockfile)
+
+	output, err := p.Get("/app/host")
+	require.NoError(err)
+	assert.Equal("locked", output.Parameter.Value)
+
+	output, err = p.Get("/app/host", secretlamb.ParameterLabel("beta"))
+	require.NoError(err)
+	assert.Equal("beta", output.Parameter.Value)
+
+	output, err = p.Get("/app/other")
+	require.NoError(err)
+	assert.Equal("unlocked", output.Parameter.Value)
+
+	s := secretlamb.MustNewSecrets().WithLockfile(lockfile)
+
+	secret, err := s.Get("prod/db#password")
+	require.NoError(err)
+	assert.Equal("tiger", secret.SecretString)
+
+	secret, err = s.Get("prod/db#password", secretlamb.SecretVersionStage("AWSPENDING"))
+	require.NoError(err)
+	assert.Equal("lion", secret.SecretString)
+}
+
+func TestWithLockfileByARN(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mine := `{"ARN":"arn:aws:secretsmanager:us-west-2:222222222222:secret:prod/api-a1b2c3","Name":"prod/api","VersionId":"v9","SecretString":"API"}`
+	locked := `{"ARN":"arn:aws:secretsmanager:us-west-2:222222222222:secret:prod/db-a1b2c3","Name":"prod/db","VersionId":"v1","SecretString":"LOCKED"}`
+	theirs := `{"ARN":"arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef","Name":"prod/db","VersionId":"v5","SecretString":"THEIRS"}`
+	theirAPI := `{"ARN":"arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/api-123456","Name":"prod/api","VersionId":"v7","SecretString":"THEIR API"}`
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fapi", httpmock.NewStringResponder(http.StatusOK, mine))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb&versionId=v1", httpmock.NewStringResponder(http.StatusOK, locked))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A222222222222%3Asecret%3Aprod%2Fdb-a1b2c3&versionId=v1", httpmock.NewStringResponder(http.StatusOK, locked))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A111111111111%3Asecret%3Aprod%2Fdb-abcdef", httpmock.NewStringResponder(http.StatusOK, theirs))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A111111111111%3Asecret%3Aprod%2Fapi-123456&versionId=v7", httpmock.NewStringResponder(http.StatusOK, theirAPI))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A111111111111%3Asecret%3Aprod%2Fapi&versionId=v7", httpmock.NewStringResponder(http.StatusOK, theirAPI))
+
+	lockfile := &secretlamb.Lockfile{
+		Secrets: map[string]string{
+			"prod/db": "v1",
+			"arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/api-123456": "v7",
+		},
+	}
+
+	s := secretlamb.MustNewSecrets().WithLockfile(lockfile)
+
+	// The caller's account is learned from a request by name.
+	secret, err := s.Get("prod/api")
+	require.NoError(err)
+	assert.Equal("API", secret.SecretString)
+
+	// The full ARN of prod/db is confirmed by the response.
+	secret, err = s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal("LOCKED", secret.SecretString)
+
+	secret, err = s.Get("arn:aws:secretsmanager:us-west-2:222222222222:secret:prod/db-a1b2c3")
+	require.NoError(err)
+	assert.Equal("LOCKED", secret.SecretString)
+
+	// prod/db of another account is not pinned.
+	secret, err = s.Get("arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/db-abcdef")
+	require.NoError(err)
+	assert.Equal("THEIRS", secret.SecretString)
+
+	// A partial ARN is pinned by the full ARN once a response has confirmed it.
+	_, err = s.Get("arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/api-123456")
+	require.NoError(err)
+	secret, err = s.Get("arn:aws:secretsmanager:us-west-2:111111111111:secret:prod/api")
+	require.NoError(err)
+	assert.Equal("THEIR API", secret.SecretString)
+	assert.Equal(6, httpmock.GetTotalCallCount())
+}
+
+func TestWithLockfileByPartialARNsSharingPrefix(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	config := `{"ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config-Ab12Cd","Name":"app-config","VersionId":"v1","SecretString":"CONFIG"}`
+	backup := `{"ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:app-backup-Ef34Gh","Name":"app-backup","VersionId":"v2","SecretString":"BACKUP"}`
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A123456789012%3Asecret%3Aapp-config&versionId=v1", httpmock.NewStringResponder(http.StatusOK, config))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=arn%3Aaws%3Asecretsmanager%3Aus-west-2%3A123456789012%3Asecret%3Aapp-backup", httpmock.NewStringResponder(http.StatusOK, backup))
+
+	lockfile := &secretlamb.Lockfile{
+		Secrets: map[string]string{
+			"arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config": "v1",
+		},
+	}
+
+	s := secretlamb.MustNewSecrets().WithLockfile(lockfile)
+
+	secret, err := s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:app-config")
+	require.NoError(err)
+	assert.Equal("CONFIG", secret.SecretString)
+
+	// app-backup is another secret, so the pin of app-config does not apply.
+	secret, err = s.Get("arn:aws:secretsmanager:us-west-2:123456789012:secret:app-backup")
+	require.NoError(err)
+	assert.Equal("BACKUP", secret.SecretString)
+}
@@ -0,0 +1,105 @@
+package secretlamb
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+type LogOptions struct {
+	RequestLevel  slog.Level
+	RetryLevel    slog.Level
+	ResponseLevel slog.Level
+	ErrorLevel    slog.Level
+	// HashNames logs a hash of the parameter name/secret ID instead of the name.
+	HashNames bool
+}
+
+var DefaultLogOptions = LogOptions{
+	RequestLevel:  slog.LevelDebug,
+	RetryLevel:    slog.LevelWarn,
+	ResponseLevel: slog.LevelDebug,
+	ErrorLevel:    slog.LevelError,
+}
+
+// slogObserver logs extension calls. Response bodies are only part of errors,
+// which the extension returns with a non-200 status, so values are never logged.
+type slogObserver struct {
+	logger  *slog.Logger
+	options LogOptions
+}
+
+func (client *client) withLogger(logger *slog.Logger, options *LogOptions) {
+	if options == nil {
+		options = &DefaultLogOptions
+	}
+
+	if client.logger == nil {
+		client.logger = &slogObserver{}
+		client.observers = append(client.observers, client.logger)
+	}
+
+	client.logger.logger = logger
+	client.logger.options = *options
+}
+
+func (client *client) logUnmarshalError(ctx context.Context, query *url.Values, err error) {
+	if client.logger == nil {
+		return
+	}
+
+	o := client.logger
+	o.logger.LogAttrs(ctx, o.options.ErrorLevel, "secretlamb: failed to unmarshal extension response",
+		slog.String("service", client.service),
+		o.nameAttr(query.Get(client.nameKey)),
+		slog.String("error", err.Error()),
+	)
+}
+
+func (o *slogObserver) nameAttr(name string) slog.Attr {
+	if o.options.HashNames {
+		return slog.String("name_hash", hashName(name))
+	}
+
+	return slog.String("name", name)
+}
+
+func (o *slogObserver) attrs(ev *Event) []slog.Attr {
+	return []slog.Attr{
+		slog.String("service", ev.Service),
+		o.nameAttr(ev.name),
+		slog.String("options", ev.Options.Encode()),
+	}
+}
+
+func (o *slogObserver) OnRequest(ctx context.Context, ev *Event) (context.Context, error) {
+	o.logger.LogAttrs(ctx, o.options.RequestLevel, "secretlamb: request", o.attrs(ev)...)
+	return ctx, nil
+}
+
+func (o *slogObserver) OnRetry(ctx context.Context, ev *Event) {
+	attrs := append(o.attrs(ev), slog.Int("attempt", ev.Attempt), slog.String("reason", ev.RetryReason))
+	o.logger.LogAttrs(ctx, o.options.RetryLevel, "secretlamb: retry", attrs...)
+}
+
+func (o *slogObserver) OnResponse(ctx context.Context, ev *Event) {
+	attrs := append(o.attrs(ev),
+		slog.Int("status", ev.StatusCode),
+		slog.Duration("duration", ev.Duration),
+		slog.Int("attempt", ev.Attempt),
+		slog.Bool("cache_hit", ev.CacheHit),
+	)
+
+	o.logger.LogAttrs(ctx, o.options.ResponseLevel, "secretlamb: response", attrs...)
+}
+
+func (o *slogObserver) OnError(ctx context.Context, ev *Event) {
+	attrs := append(o.attrs(ev),
+		slog.Int("status", ev.StatusCode),
+		slog.Duration("duration", ev.Duration),
+		slog.Int("attempt", ev.Attempt),
+		slog.String("error", ev.Err.Error()),
+	)
+
+	o.logger.LogAttrs(ctx, o.options.ErrorLevel, "secretlamb: request failed", attrs...)
+}
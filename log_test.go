@@ -0,0 +1,110 @@
+package secretlamb_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func readLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	lines := []map[string]any{}
+	scanner := bufio.NewScanner(buf)
+
+	for scanner.Scan() {
+		line := map[string]any{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		delete(line, "time")
+		delete(line, "duration")
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestWithLogger(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "Vidi")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bar", httpmock.NewStringResponder(http.StatusBadRequest, "ResourceNotFoundException"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=baz", httpmock.NewStringResponder(http.StatusOK, `{"SecretString": "Vidi"`))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := secretlamb.MustNewSecrets().WithLogger(logger, nil)
+
+	_, err := s.Get("foo")
+	require.NoError(err)
+	_, err = s.Get("bar")
+	require.Error(err)
+	_, err = s.Get("baz")
+	require.Error(err)
+
+	assert.NotContains(buf.String(), "Vidi")
+
+	assert.Equal([]map[string]any{
+		{"level": "DEBUG", "msg": "secretlamb: request", "service": "secretsmanager", "name": "foo", "options": ""},
+		{"level": "DEBUG", "msg": "secretlamb: response", "service": "secretsmanager", "name": "foo", "options": "", "status": float64(200), "attempt": float64(0), "cache_hit": false},
+		{"level": "DEBUG", "msg": "secretlamb: request", "service": "secretsmanager", "name": "bar", "options": ""},
+		{"level": "ERROR", "msg": "secretlamb: request failed", "service": "secretsmanager", "name": "bar", "options": "", "status": float64(400), "attempt": float64(0), "error": "400 Bad Request: ResourceNotFoundException"},
+		{"level": "DEBUG", "msg": "secretlamb: request", "service": "secretsmanager", "name": "baz", "options": ""},
+		{"level": "DEBUG", "msg": "secretlamb: response", "service": "secretsmanager", "name": "baz", "options": "", "status": float64(200), "attempt": float64(0), "cache_hit": false},
+		{"level": "ERROR", "msg": "secretlamb: failed to unmarshal extension response", "service": "secretsmanager", "name": "baz", "error": "unexpected end of JSON input"},
+	}, readLogLines(t, &buf))
+}
+
+func TestWithLoggerHashNames(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12778")
+	assert := assert.New(t)
+	require := require.New(t)
+
+	try := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		try++
+
+		if try == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
+			return
+		}
+
+		fmt.Fprint(w, parameterResponse(t, 1, "Veni"))
+	})
+
+	l, _ := net.Listen("tcp", ":12778")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	options := secretlamb.DefaultLogOptions
+	options.HashNames = true
+	options.ResponseLevel = slog.LevelInfo
+	p := secretlamb.MustNewParameters().WithRetry(1).WithLogger(logger, &options)
+
+	_, err := p.Get("foo")
+	require.NoError(err)
+
+	assert.Equal([]map[string]any{
+		{"level": "WARN", "msg": "secretlamb: retry", "service": "ssm", "name_hash": "2c26b46b68ffc68f", "options": "", "attempt": float64(1), "reason": "status 400 Bad Request"},
+		{"level": "INFO", "msg": "secretlamb: response", "service": "ssm", "name_hash": "2c26b46b68ffc68f", "options": "", "status": float64(200), "attempt": float64(1), "cache_hit": false},
+	}, readLogLines(t, &buf))
+}
@@ -0,0 +1,129 @@
+package secretlamb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+)
+
+type eventKey struct{}
+
+// Event describes one lookup through the extension.
+// Name is a hash of the parameter name/secret ID so that observers cannot leak it.
+type Event struct {
+	Service string
+	Name    string
+	Options url.Values
+	Attempt int
+	// RetryReason is why the previous attempt was retried.
+	RetryReason string
+	Duration    time.Duration
+	StatusCode  int
+	CacheHit    bool
+	Err         error
+	name        string
+}
+
+// Observer is called around every extension call.
+// OnRequest may return a derived context (e.g. with a span) that is passed to the other callbacks,
+// or an error to fail the request without calling the extension.
+// A lookup served from the cache only calls OnResponse with CacheHit set.
+type Observer interface {
+	OnRequest(ctx context.Context, ev *Event) (context.Context, error)
+	OnRetry(ctx context.Context, ev *Event)
+	OnResponse(ctx context.Context, ev *Event)
+	OnError(ctx context.Context, ev *Event)
+}
+
+// ObserverFuncs is an Observer whose nil callbacks do nothing.
+type ObserverFuncs struct {
+	Request  func(ctx context.Context, ev *Event) (context.Context, error)
+	Retry    func(ctx context.Context, ev *Event)
+	Response func(ctx context.Context, ev *Event)
+	Error    func(ctx context.Context, ev *Event)
+}
+
+func (o *ObserverFuncs) OnRequest(ctx context.Context, ev *Event) (context.Context, error) {
+	if o.Request == nil {
+		return ctx, nil
+	}
+
+	return o.Request(ctx, ev)
+}
+
+func (o *ObserverFuncs) OnRetry(ctx context.Context, ev *Event) {
+	if o.Retry != nil {
+		o.Retry(ctx, ev)
+	}
+}
+
+func (o *ObserverFuncs) OnResponse(ctx context.Context, ev *Event) {
+	if o.Response != nil {
+		o.Response(ctx, ev)
+	}
+}
+
+func (o *ObserverFuncs) OnError(ctx context.Context, ev *Event) {
+	if o.Error != nil {
+		o.Error(ctx, ev)
+	}
+}
+
+func newEvent(service string, nameKey string, query *url.Values) *Event {
+	name := query.Get(nameKey)
+	options := url.Values{}
+
+	for key, values := range *query {
+		if key != nameKey {
+			options[key] = values
+		}
+	}
+
+	ev := &Event{
+		Service: service,
+		Name:    hashName(name),
+		Options: options,
+		name:    name,
+	}
+
+	return ev
+}
+
+func (client *client) notifyRequest(ctx context.Context, ev *Event) (context.Context, error) {
+	for _, o := range client.observers {
+		var err error
+		ctx, err = o.OnRequest(ctx, ev)
+
+		if err != nil {
+			return ctx, err
+		}
+	}
+
+	return ctx, nil
+}
+
+func (client *client) notifyRetry(ctx context.Context, ev *Event) {
+	for _, o := range client.observers {
+		o.OnRetry(ctx, ev)
+	}
+}
+
+func (client *client) notifyResponse(ctx context.Context, ev *Event) {
+	for _, o := range client.observers {
+		o.OnResponse(ctx, ev)
+	}
+}
+
+func (client *client) notifyError(ctx context.Context, ev *Event) {
+	for _, o := range client.observers {
+		o.OnError(ctx, ev)
+	}
+}
+
+// hashName identifies a parameter/secret name in telemetry without revealing it.
+func hashName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,135 @@
+package secretlamb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(kind string, ev *secretlamb.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf("%s %s %s %v attempt=%d status=%d cache=%t err=%t", kind, ev.Service, ev.Name, ev.Options, ev.Attempt, ev.StatusCode, ev.CacheHit, ev.Err != nil))
+}
+
+func (o *recordingObserver) OnRequest(ctx context.Context, ev *secretlamb.Event) (context.Context, error) {
+	o.record("request", ev)
+	return ctx, nil
+}
+
+func (o *recordingObserver) OnRetry(ctx context.Context, ev *secretlamb.Event) {
+	o.record("retry", ev)
+}
+
+func (o *recordingObserver) OnResponse(ctx context.Context, ev *secretlamb.Event) {
+	o.record("response", ev)
+}
+
+func (o *recordingObserver) OnError(ctx context.Context, ev *secretlamb.Event) {
+	o.record("error", ev)
+}
+
+func TestObserver(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=foo&version=1", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "Veni")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=bar", httpmock.NewStringResponder(http.StatusBadRequest, "ParameterNotFound"))
+
+	o := &recordingObserver{}
+	p := secretlamb.MustNewParameters().WithCache(secretlamb.NewCache(0)).WithObserver(o)
+
+	_, err := p.Get("foo", secretlamb.ParameterVersion(1))
+	require.NoError(err)
+	_, err = p.Get("foo", secretlamb.ParameterVersion(1))
+	require.NoError(err)
+	_, err = p.Get("bar")
+	require.Error(err)
+
+	assert.Equal([]string{
+		"request ssm 2c26b46b68ffc68f map[version:[1]] attempt=0 status=0 cache=false err=false",
+		"response ssm 2c26b46b68ffc68f map[version:[1]] attempt=0 status=200 cache=false err=false",
+		"response ssm 2c26b46b68ffc68f map[version:[1]] attempt=0 status=0 cache=true err=false",
+		"request ssm fcde2b2edba56bf4 map[] attempt=0 status=0 cache=false err=false",
+		"error ssm fcde2b2edba56bf4 map[] attempt=0 status=400 cache=false err=true",
+	}, o.events)
+}
+
+func TestObserverAbortRequest(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var observed error
+
+	chaos := &secretlamb.ObserverFuncs{
+		Request: func(ctx context.Context, ev *secretlamb.Event) (context.Context, error) {
+			return ctx, errors.New("chaos")
+		},
+		Error: func(ctx context.Context, ev *secretlamb.Event) {
+			observed = ev.Err
+		},
+	}
+
+	s := secretlamb.MustNewSecrets().WithObserver(chaos)
+	_, err := s.Get("foo")
+	assert.ErrorContains(err, "failed to get secret - http request error: chaos")
+	assert.EqualError(observed, "chaos")
+	assert.Equal(0, httpmock.GetTotalCallCount())
+}
+
+func TestObserverWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12777")
+	assert := assert.New(t)
+	require := require.New(t)
+
+	try := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		try++
+
+		if try == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
+			return
+		}
+
+		fmt.Fprint(w, secretResponse(t, "v1", "Vidi"))
+	})
+
+	l, _ := net.Listen("tcp", ":12777")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	o := &recordingObserver{}
+	s := secretlamb.MustNewSecrets().WithRetry(1).WithObserver(o)
+	_, err := s.Get("foo", secretlamb.SecretVersionStage("AWSCURRENT"))
+	require.NoError(err)
+
+	assert.Equal([]string{
+		"request secretsmanager 2c26b46b68ffc68f map[versionStage:[AWSCURRENT]] attempt=0 status=0 cache=false err=false",
+		"retry secretsmanager 2c26b46b68ffc68f map[versionStage:[AWSCURRENT]] attempt=1 status=0 cache=false err=false",
+		"response secretsmanager 2c26b46b68ffc68f map[versionStage:[AWSCURRENT]] attempt=1 status=200 cache=false err=false",
+	}, o.events)
+}
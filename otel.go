@@ -0,0 +1,125 @@
+package secretlamb
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/winebarrel/secretlamb"
+
+// otelObserver never records names or values, only a hash of the name.
+type otelObserver struct {
+	tracer    trace.Tracer
+	duration  metric.Float64Histogram
+	errors    metric.Int64Counter
+	retries   metric.Int64Counter
+	cacheHits metric.Int64Counter
+}
+
+func (client *client) otelObserver() *otelObserver {
+	if client.otel == nil {
+		client.otel = &otelObserver{tracer: tracenoop.NewTracerProvider().Tracer(instrumentationName)}
+		_ = client.otel.setMeterProvider(metricnoop.NewMeterProvider())
+		client.observers = append(client.observers, client.otel)
+	}
+
+	return client.otel
+}
+
+func (client *client) withTracerProvider(tp trace.TracerProvider) {
+	client.otelObserver().tracer = tp.Tracer(instrumentationName)
+}
+
+func (client *client) withMeterProvider(mp metric.MeterProvider) {
+	if err := client.otelObserver().setMeterProvider(mp); err != nil {
+		otel.Handle(err)
+	}
+}
+
+func (o *otelObserver) setMeterProvider(mp metric.MeterProvider) error {
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("secretlamb.request.duration", metric.WithUnit("s"), metric.WithDescription("Duration of requests to the extension."))
+
+	if err != nil {
+		return err
+	}
+
+	errors, err := meter.Int64Counter("secretlamb.request.errors", metric.WithDescription("Number of failed requests to the extension."))
+
+	if err != nil {
+		return err
+	}
+
+	retries, err := meter.Int64Counter("secretlamb.request.retries", metric.WithDescription("Number of retried requests to the extension."))
+
+	if err != nil {
+		return err
+	}
+
+	cacheHits, err := meter.Int64Counter("secretlamb.cache.hits", metric.WithDescription("Number of lookups served from the cache."))
+
+	if err != nil {
+		return err
+	}
+
+	o.duration = duration
+	o.errors = errors
+	o.retries = retries
+	o.cacheHits = cacheHits
+
+	return nil
+}
+
+func (o *otelObserver) OnRequest(ctx context.Context, ev *Event) (context.Context, error) {
+	ctx, _ = o.tracer.Start(ctx, "secretlamb.get",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("secretlamb.service", ev.Service),
+			attribute.String("secretlamb.name_hash", ev.Name),
+		),
+	)
+
+	return ctx, nil
+}
+
+func (o *otelObserver) OnRetry(ctx context.Context, ev *Event) {
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(attribute.Int("secretlamb.attempt", ev.Attempt)))
+	o.retries.Add(ctx, 1, metric.WithAttributes(attribute.String("secretlamb.service", ev.Service)))
+}
+
+func (o *otelObserver) OnResponse(ctx context.Context, ev *Event) {
+	if ev.CacheHit {
+		o.cacheHits.Add(ctx, 1, metric.WithAttributes(attribute.String("secretlamb.service", ev.Service)))
+		return
+	}
+
+	o.end(ctx, ev)
+}
+
+func (o *otelObserver) OnError(ctx context.Context, ev *Event) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(ev.Err)
+	span.SetStatus(codes.Error, "request to the extension failed")
+	o.errors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("secretlamb.service", ev.Service),
+		attribute.Int("http.response.status_code", ev.StatusCode),
+	))
+	o.end(ctx, ev)
+}
+
+func (o *otelObserver) end(ctx context.Context, ev *Event) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", ev.StatusCode),
+		attribute.Int("secretlamb.retry_count", ev.Attempt),
+	)
+	span.End()
+	o.duration.Record(ctx, ev.Duration.Seconds(), metric.WithAttributes(attribute.String("secretlamb.service", ev.Service)))
+}
@@ -0,0 +1,86 @@
+package secretlamb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOpenTelemetry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=foo", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "Veni")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bar", httpmock.NewStringResponder(http.StatusBadRequest, "ResourceNotFoundException"))
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	s := secretlamb.MustNewSecrets().
+		WithCache(secretlamb.NewCache(0)).
+		WithTracerProvider(tp).
+		WithMeterProvider(mp)
+
+	_, err := s.Get("foo")
+	require.NoError(err)
+	_, err = s.Get("foo")
+	require.NoError(err)
+	_, err = s.Get("bar")
+	require.Error(err)
+
+	spans := exporter.GetSpans()
+	require.Len(spans, 2)
+	assert.Equal("secretlamb.get", spans[0].Name)
+	assert.ElementsMatch([]attribute.KeyValue{
+		attribute.String("secretlamb.service", "secretsmanager"),
+		attribute.String("secretlamb.name_hash", "2c26b46b68ffc68f"),
+		attribute.Int("http.response.status_code", 200),
+		attribute.Int("secretlamb.retry_count", 0),
+	}, spans[0].Attributes)
+	assert.Equal(codes.Error, spans[1].Status.Code)
+
+	for _, span := range spans {
+		for _, attr := range span.Attributes {
+			assert.NotContains(attr.Value.Emit(), "Veni")
+			assert.NotEqual("foo", attr.Value.Emit())
+		}
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(reader.Collect(context.Background(), &rm))
+	sums := map[string]int64{}
+	histogramCounts := map[string]uint64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					sums[m.Name] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					histogramCounts[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+
+	assert.Equal(map[string]int64{"secretlamb.cache.hits": 1, "secretlamb.request.errors": 1}, sums)
+	assert.Equal(map[string]uint64{"secretlamb.request.duration": 2}, histogramCounts)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strconv"
+	"time"
 
-	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Parameters struct {
@@ -22,6 +25,7 @@ type ParameterOutputParameter struct {
 	LastModifiedDate string `json:"LastModifiedDate"`
 	Arn              string `json:"ARN"`
 	DataType         string `json:"DataType"`
+	SourceResult     string `json:"SourceResult"`
 }
 
 type ParameterOutput struct {
@@ -31,6 +35,8 @@ type ParameterOutput struct {
 type ParameterOption struct {
 	Key   string
 	Value string
+	// transform is applied to the output instead of being sent to the extension.
+	transform func(ctx context.Context, output *ParameterOutput) error
 }
 
 func ParameterVersion(version int) *ParameterOption {
@@ -55,7 +61,7 @@ func ParameterWithDecryption() *ParameterOption {
 }
 
 func NewParameters() (*Parameters, error) {
-	client, err := newClient("/systemsmanager/parameters/get/")
+	client, err := newClient(ServiceParameters, "name", "/systemsmanager/parameters/get/")
 	return &Parameters{client: client}, err
 }
 
@@ -70,10 +76,61 @@ func MustNewParameters() *Parameters {
 }
 
 func (p *Parameters) WithRetry(retryMax int) *Parameters {
-	retryClient := retryablehttp.NewClient()
-	retryClient.RetryMax = retryMax
-	retryClient.CheckRetry = retryPolicy
-	p.HTTPClient = retryClient.StandardClient()
+	p.withRetry(retryMax)
+	return p
+}
+
+// WithDeadlineBudget stops requests (including retries) safety before the deadline of the context,
+// and limits each attempt to attemptTimeout (zero means no per-attempt limit).
+func (p *Parameters) WithDeadlineBudget(safety time.Duration, attemptTimeout time.Duration) *Parameters {
+	p.withDeadlineBudget(safety, attemptTimeout)
+	return p
+}
+
+func (p *Parameters) WithCache(cache *Cache) *Parameters {
+	p.cache = cache
+	return p
+}
+
+func (p *Parameters) WithTracerProvider(tp trace.TracerProvider) *Parameters {
+	p.withTracerProvider(tp)
+	return p
+}
+
+func (p *Parameters) WithMeterProvider(mp metric.MeterProvider) *Parameters {
+	p.withMeterProvider(mp)
+	return p
+}
+
+func (p *Parameters) WithEMF(emitter *EMFEmitter) *Parameters {
+	p.withObserver(emitter)
+	return p
+}
+
+func (p *Parameters) WithObserver(o Observer) *Parameters {
+	p.withObserver(o)
+	return p
+}
+
+func (p *Parameters) WithLogger(logger *slog.Logger, options *LogOptions) *Parameters {
+	p.withLogger(logger, options)
+	return p
+}
+
+func (p *Parameters) WithAuditRecorder(recorder *AuditRecorder) *Parameters {
+	p.auditor = recorder
+	return p
+}
+
+// WithDecrypter decrypts every value with d before other options such as ParameterDecode are applied.
+func (p *Parameters) WithDecrypter(d Decrypter) *Parameters {
+	p.decrypter = d
+	return p
+}
+
+// WithLockfile applies the version locked in l unless a version or label option is given.
+func (p *Parameters) WithLockfile(l *Lockfile) *Parameters {
+	p.lockfile = l
 	return p
 }
 
@@ -82,6 +139,16 @@ func (p *Parameters) Get(name string, options ...*ParameterOption) (*ParameterOu
 }
 
 func (p *Parameters) GetWithContext(ctx context.Context, name string, options ...*ParameterOption) (*ParameterOutput, error) {
+	options, transforms := splitParameterOptions(options)
+
+	if p.lockfile != nil {
+		options = p.lockfile.parameterOptions(name, p.canonicalName, options)
+	}
+
+	if d := p.clientDecrypter(ctx); d != nil {
+		transforms = append([]*ParameterOption{ParameterDecrypt(d)}, transforms...)
+	}
+
 	query := &url.Values{}
 	query.Add("name", name)
 
@@ -89,6 +156,51 @@ func (p *Parameters) GetWithContext(ctx context.Context, name string, options ..
 		query.Add(opt.Key, opt.Value)
 	}
 
+	output, err := p.getParameter(ctx, query)
+
+	if p.auditor != nil {
+		p.auditParameter(ctx, query, output, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyParameterTransforms(ctx, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter - %w", err)
+	}
+
+	return output, nil
+}
+
+func splitParameterOptions(options []*ParameterOption) ([]*ParameterOption, []*ParameterOption) {
+	query := []*ParameterOption{}
+	transforms := []*ParameterOption{}
+
+	for _, opt := range options {
+		if opt.transform != nil {
+			transforms = append(transforms, opt)
+		} else {
+			query = append(query, opt)
+		}
+	}
+
+	return query, transforms
+}
+
+func applyParameterTransforms(ctx context.Context, output *ParameterOutput, transforms []*ParameterOption) error {
+	for _, opt := range transforms {
+		if err := opt.transform(ctx, output); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Parameters) getParameter(ctx context.Context, query *url.Values) (*ParameterOutput, error) {
 	body, err := p.get(ctx, query)
 
 	if err != nil {
@@ -99,6 +211,7 @@ func (p *Parameters) GetWithContext(ctx context.Context, name string, options ..
 	err = json.Unmarshal(body, output)
 
 	if err != nil {
+		p.logUnmarshalError(ctx, query, err)
 		return nil, fmt.Errorf("failed to get parameter - json unmarshal error: %w", err)
 	}
 
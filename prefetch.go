@@ -0,0 +1,89 @@
+package secretlamb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+const PrefetchEnv = "SECRETLAMB_PREFETCH"
+
+var ready atomic.Bool
+
+// Prefetch waits for the extension with WaitReady, then concurrently loads refs into DefaultCache without retries,
+// so a missing ref fails at once instead of using up the init phase.
+// Parameters are fetched with decryption, so read them with GetWithDecryption() on a client using WithCache(DefaultCache).
+func Prefetch(ctx context.Context, refs ...Ref) error {
+	p, err := NewParameters()
+
+	if err != nil {
+		return fmt.Errorf("failed to prefetch - %w", err)
+	}
+
+	s, err := NewSecrets()
+
+	if err != nil {
+		return fmt.Errorf("failed to prefetch - %w", err)
+	}
+
+	p = p.WithCache(DefaultCache)
+	s = s.WithCache(DefaultCache)
+
+	// The extension answers "400 Bad Request: not ready to serve traffic" until it has started.
+	err = p.WaitReady(ctx)
+
+	if err != nil {
+		ready.Store(false)
+		return fmt.Errorf("failed to prefetch - %w", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(refs))
+
+	for i, ref := range refs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			var err error
+
+			switch ref.Service {
+			case RefServiceParameter:
+				_, err = p.GetWithContext(ctx, ref.Name, ParameterWithDecryption())
+			case RefServiceSecret:
+				_, err = s.GetWithContext(ctx, ref.Name, nil)
+			default:
+				err = fmt.Errorf("unknown service %q", ref.Service)
+			}
+
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to prefetch %s: %w", ref, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	err = errors.Join(errs...)
+	ready.Store(err == nil)
+
+	return err
+}
+
+// PrefetchFromEnv prefetches the comma-separated refs in SECRETLAMB_PREFETCH (e.g. "ssm:/a,secret:b").
+func PrefetchFromEnv(ctx context.Context) error {
+	refs, err := ParseRefs(os.Getenv(PrefetchEnv))
+
+	if err != nil {
+		return fmt.Errorf("failed to prefetch - %w", err)
+	}
+
+	return Prefetch(ctx, refs...)
+}
+
+// Ready reports whether the last Prefetch loaded every ref.
+func Ready() bool {
+	return ready.Load()
+}
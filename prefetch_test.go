@@ -0,0 +1,121 @@
+package secretlamb_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestPrefetchFromEnv(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12774")
+	t.Setenv("SECRETLAMB_PREFETCH", "ssm:/a,secret:b")
+	secretlamb.DefaultCache.Clear()
+	defer secretlamb.DefaultCache.Clear()
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	notReady := 2
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if notReady > 0 {
+			notReady--
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
+			return
+		}
+
+		if r.URL.RawQuery == "" {
+			// readiness probe
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		calls[r.URL.String()]++
+
+		switch r.URL.Path {
+		case "/systemsmanager/parameters/get/":
+			fmt.Fprint(w, parameterResponse(t, 1, "Veni"))
+		case "/secretsmanager/get":
+			fmt.Fprint(w, secretResponse(t, "v1", "Vidi"))
+		}
+	})
+
+	l, _ := net.Listen("tcp", ":12774")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	err := secretlamb.PrefetchFromEnv(context.Background())
+	require.NoError(err)
+	assert.True(secretlamb.Ready())
+	assert.Equal(2, secretlamb.DefaultCache.Len())
+
+	p := secretlamb.MustNewParameters().WithCache(secretlamb.DefaultCache)
+	pv, err := p.GetWithDecryption("/a")
+	require.NoError(err)
+	assert.Equal("Veni", pv.Parameter.Value)
+
+	s := secretlamb.MustNewSecrets().WithCache(secretlamb.DefaultCache)
+	sv, err := s.Get("b")
+	require.NoError(err)
+	assert.Equal("Vidi", sv.SecretString)
+
+	assert.Equal(map[string]int{
+		"/systemsmanager/parameters/get/?name=%2Fa&withDecryption=true": 1,
+		"/secretsmanager/get?secretId=b":                                1,
+	}, calls)
+}
+
+func TestPrefetchErr(t *testing.T) {
+	t.Setenv("SECRETLAMB_PREFETCH", "s3:foo")
+	assert := assert.New(t)
+
+	err := secretlamb.PrefetchFromEnv(context.Background())
+	assert.ErrorContains(err, `unknown service "s3"`)
+}
+
+func TestPrefetchNotFound(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12782")
+	secretlamb.DefaultCache.Clear()
+	defer secretlamb.DefaultCache.Clear()
+
+	assert := assert.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "ParameterNotFound")
+	})
+
+	l, _ := net.Listen("tcp", ":12782")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// A missing ref fails without retries.
+	start := time.Now()
+	err := secretlamb.Prefetch(context.Background(), secretlamb.Ref{Service: secretlamb.RefServiceParameter, Name: "/missing"})
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.Less(time.Since(start), time.Second)
+	assert.False(secretlamb.Ready())
+}
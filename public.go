@@ -0,0 +1,182 @@
+package secretlamb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+const (
+	DataTypeText     = "text"
+	DataTypeEC2Image = "aws:ec2:image"
+)
+
+type Architecture string
+
+const (
+	ArchX86_64 Architecture = "x86_64"
+	ArchARM64  Architecture = "arm64"
+)
+
+type DataTypeError struct {
+	Name     string
+	Expected []string
+	Actual   string
+}
+
+func (e *DataTypeError) Error() string {
+	return fmt.Sprintf("unexpected data type of %s: %q (expected %q)", e.Name, e.Actual, e.Expected)
+}
+
+// PublicParameters reads the AWS public parameters under /aws/service.
+// Listing (e.g. all regions, or the availability zones of a service) needs GetParametersByPath,
+// which the extension does not support, so only single parameters are available.
+type PublicParameters struct {
+	parameters ParameterGetter
+}
+
+// Public values are never encrypted, so the decrypter set by WithDecrypter is not applied.
+func (p *Parameters) Public() *PublicParameters {
+	return NewPublicParameters(undecryptedParameters{p})
+}
+
+func NewPublicParameters(p ParameterGetter) *PublicParameters {
+	return &PublicParameters{parameters: p}
+}
+
+type AMIParameter struct {
+	Path string
+}
+
+func AmazonLinux2023AMI(arch Architecture) AMIParameter {
+	return AMIParameter{Path: "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-" + string(arch)}
+}
+
+func AmazonLinux2AMI(arch Architecture) AMIParameter {
+	return AMIParameter{Path: "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-" + string(arch) + "-gp2"}
+}
+
+func ECSOptimizedAMI(arch Architecture) AMIParameter {
+	if arch == ArchX86_64 {
+		return AMIParameter{Path: "/aws/service/ecs/optimized-ami/amazon-linux-2023/recommended/image_id"}
+	}
+
+	return AMIParameter{Path: "/aws/service/ecs/optimized-ami/amazon-linux-2023/" + string(arch) + "/recommended/image_id"}
+}
+
+func EKSOptimizedAMI(kubernetesVersion string, arch Architecture) AMIParameter {
+	return AMIParameter{Path: "/aws/service/eks/optimized-ami/" + kubernetesVersion + "/amazon-linux-2023/" + string(arch) + "/standard/recommended/image_id"}
+}
+
+// BottlerocketAMI takes a variant such as "aws-k8s-1.29" or "aws-ecs-2".
+func BottlerocketAMI(variant string, arch Architecture) AMIParameter {
+	return AMIParameter{Path: "/aws/service/bottlerocket/" + variant + "/" + string(arch) + "/latest/image_id"}
+}
+
+// BottlerocketVersion returns the version of the latest Bottlerocket image, e.g. "1.20.0".
+func (pub *PublicParameters) BottlerocketVersion(ctx context.Context, variant string, arch Architecture) (string, error) {
+	output, err := pub.get(ctx, "/aws/service/bottlerocket/"+variant+"/"+string(arch)+"/latest/image_version", DataTypeText)
+
+	if err != nil {
+		return "", err
+	}
+
+	return output.Value, nil
+}
+
+type AMI struct {
+	ImageID          string
+	Version          int64
+	LastModifiedDate string
+}
+
+var amiIDPattern = regexp.MustCompile(`^ami-[0-9a-f]+$`)
+
+func (pub *PublicParameters) AMI(ctx context.Context, param AMIParameter) (*AMI, error) {
+	output, err := pub.get(ctx, param.Path, DataTypeEC2Image, DataTypeText)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !amiIDPattern.MatchString(output.Value) {
+		return nil, fmt.Errorf("failed to get public parameter - invalid image ID of %s: %q", param.Path, output.Value)
+	}
+
+	ami := &AMI{
+		ImageID:          output.Value,
+		Version:          output.Version,
+		LastModifiedDate: output.LastModifiedDate,
+	}
+
+	return ami, nil
+}
+
+type Region struct {
+	Name      string
+	LongName  string
+	Partition string
+	Domain    string
+}
+
+func (pub *PublicParameters) Region(ctx context.Context, region string) (*Region, error) {
+	prefix := "/aws/service/global-infrastructure/regions/" + region
+	r := &Region{Name: region}
+
+	for _, field := range []struct {
+		name string
+		dst  *string
+	}{
+		{"longName", &r.LongName},
+		{"partition", &r.Partition},
+		{"domain", &r.Domain},
+	} {
+		output, err := pub.get(ctx, prefix+"/"+field.name, DataTypeText)
+
+		if err != nil {
+			return nil, err
+		}
+
+		*field.dst = output.Value
+	}
+
+	return r, nil
+}
+
+func (pub *PublicParameters) ServiceLongName(ctx context.Context, service string) (string, error) {
+	output, err := pub.get(ctx, "/aws/service/global-infrastructure/services/"+service+"/longName", DataTypeText)
+
+	if err != nil {
+		return "", err
+	}
+
+	return output.Value, nil
+}
+
+func (pub *PublicParameters) ServiceAvailable(ctx context.Context, service string, region string) (bool, error) {
+	_, err := pub.get(ctx, "/aws/service/global-infrastructure/regions/"+region+"/services/"+service, DataTypeText)
+
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (pub *PublicParameters) get(ctx context.Context, name string, dataTypes ...string) (*ParameterOutputParameter, error) {
+	output, err := pub.parameters.GetWithContext(ctx, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(dataTypes, output.Parameter.DataType) {
+		return nil, fmt.Errorf("failed to get public parameter - %w", &DataTypeError{Name: name, Expected: dataTypes, Actual: output.Parameter.DataType})
+	}
+
+	return &output.Parameter, nil
+}
@@ -0,0 +1,113 @@
+package secretlamb_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func publicParameter(name string, value string, dataType string) *secretlamb.ParameterOutput {
+	return &secretlamb.ParameterOutput{
+		Parameter: secretlamb.ParameterOutputParameter{
+			Name:     name,
+			Type:     "String",
+			Value:    value,
+			Version:  1,
+			DataType: dataType,
+		},
+	}
+}
+
+func TestPublicAMI(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	body, err := json.Marshal(publicParameter("/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-arm64", "ami-0abcdef1234567890", "aws:ec2:image"))
+	require.NoError(err)
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Faws%2Fservice%2Fami-amazon-linux-latest%2Fal2023-ami-kernel-default-arm64", httpmock.NewBytesResponder(http.StatusOK, body))
+
+	p := secretlamb.MustNewParameters()
+	ami, err := p.Public().AMI(context.Background(), secretlamb.AmazonLinux2023AMI(secretlamb.ArchARM64))
+	require.NoError(err)
+	assert.Equal(&secretlamb.AMI{ImageID: "ami-0abcdef1234567890", Version: 1}, ami)
+}
+
+func TestPublicAMIPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-gp2", secretlamb.AmazonLinux2AMI(secretlamb.ArchX86_64).Path)
+	assert.Equal("/aws/service/ecs/optimized-ami/amazon-linux-2023/recommended/image_id", secretlamb.ECSOptimizedAMI(secretlamb.ArchX86_64).Path)
+	assert.Equal("/aws/service/ecs/optimized-ami/amazon-linux-2023/arm64/recommended/image_id", secretlamb.ECSOptimizedAMI(secretlamb.ArchARM64).Path)
+	assert.Equal("/aws/service/eks/optimized-ami/1.31/amazon-linux-2023/x86_64/standard/recommended/image_id", secretlamb.EKSOptimizedAMI("1.31", secretlamb.ArchX86_64).Path)
+	assert.Equal("/aws/service/bottlerocket/aws-k8s-1.31/arm64/latest/image_id", secretlamb.BottlerocketAMI("aws-k8s-1.31", secretlamb.ArchARM64).Path)
+}
+
+func TestPublicAMIValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	bottlerocket := secretlamb.BottlerocketAMI("aws-ecs-2", secretlamb.ArchX86_64)
+	ecs := secretlamb.ECSOptimizedAMI(secretlamb.ArchX86_64)
+	p := secretlamb.NewFakeParameters(nil)
+	p.Outputs[bottlerocket.Path] = publicParameter(bottlerocket.Path, "1.20.0", "text")
+	p.Outputs[ecs.Path] = publicParameter(ecs.Path, "ami-0abcdef1234567890", "aws:ec2:instance")
+	pub := secretlamb.NewPublicParameters(p)
+
+	_, err := pub.AMI(context.Background(), bottlerocket)
+	assert.ErrorContains(err, `invalid image ID of /aws/service/bottlerocket/aws-ecs-2/x86_64/latest/image_id: "1.20.0"`)
+
+	_, err = pub.AMI(context.Background(), ecs)
+	var dataTypeErr *secretlamb.DataTypeError
+	assert.ErrorAs(err, &dataTypeErr)
+	assert.Equal("aws:ec2:instance", dataTypeErr.Actual)
+}
+
+func TestPublicRegion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	prefix := "/aws/service/global-infrastructure"
+	p := secretlamb.NewFakeParameters(nil)
+	p.Outputs[prefix+"/regions/ap-northeast-1/longName"] = publicParameter("", "Asia Pacific (Tokyo)", "text")
+	p.Outputs[prefix+"/regions/ap-northeast-1/partition"] = publicParameter("", "aws", "text")
+	p.Outputs[prefix+"/regions/ap-northeast-1/domain"] = publicParameter("", "amazonaws.com", "text")
+	p.Outputs[prefix+"/regions/ap-northeast-1/services/lambda"] = publicParameter("", "lambda", "text")
+	p.Outputs[prefix+"/services/lambda/longName"] = publicParameter("", "AWS Lambda", "text")
+	pub := secretlamb.NewPublicParameters(p)
+
+	region, err := pub.Region(context.Background(), "ap-northeast-1")
+	require.NoError(err)
+	assert.Equal(&secretlamb.Region{Name: "ap-northeast-1", LongName: "Asia Pacific (Tokyo)", Partition: "aws", Domain: "amazonaws.com"}, region)
+
+	name, err := pub.ServiceLongName(context.Background(), "lambda")
+	require.NoError(err)
+	assert.Equal("AWS Lambda", name)
+
+	ok, err := pub.ServiceAvailable(context.Background(), "lambda", "ap-northeast-1")
+	require.NoError(err)
+	assert.True(ok)
+
+	ok, err = pub.ServiceAvailable(context.Background(), "lambda", "us-gov-west-1")
+	require.NoError(err)
+	assert.False(ok)
+}
+
+func TestPublicBottlerocketVersion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := "/aws/service/bottlerocket/aws-ecs-2/x86_64/latest/image_version"
+	p := secretlamb.NewFakeParameters(nil)
+	p.Outputs[path] = publicParameter(path, "1.20.0", "text")
+
+	version, err := secretlamb.NewPublicParameters(p).BottlerocketVersion(context.Background(), "aws-ecs-2", secretlamb.ArchX86_64)
+	require.NoError(err)
+	assert.Equal("1.20.0", version)
+}
@@ -0,0 +1,55 @@
+package secretlamb
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	RefServiceParameter = "ssm"
+	RefServiceSecret    = "secret"
+)
+
+type Ref struct {
+	Service string
+	Name    string
+}
+
+func ParseRef(s string) (Ref, error) {
+	service, name, ok := strings.Cut(strings.TrimSpace(s), ":")
+
+	if !ok || name == "" {
+		return Ref{}, fmt.Errorf("invalid reference %q - expected ssm:<name> or secret:<secret-id>", s)
+	}
+
+	switch service {
+	case RefServiceParameter, RefServiceSecret:
+		return Ref{Service: service, Name: name}, nil
+	default:
+		return Ref{}, fmt.Errorf("invalid reference %q - unknown service %q", s, service)
+	}
+}
+
+func ParseRefs(s string) ([]Ref, error) {
+	refs := []Ref{}
+
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		ref, err := ParseRef(item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+func (r Ref) String() string {
+	return r.Service + ":" + r.Name
+}
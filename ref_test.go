@@ -0,0 +1,45 @@
+package secretlamb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestParseRef(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ref, err := secretlamb.ParseRef("ssm:/app/db/host")
+	require.NoError(err)
+	assert.Equal(secretlamb.Ref{Service: secretlamb.RefServiceParameter, Name: "/app/db/host"}, ref)
+	assert.Equal("ssm:/app/db/host", ref.String())
+
+	ref, err = secretlamb.ParseRef("secret:arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3")
+	require.NoError(err)
+	assert.Equal(secretlamb.Ref{Service: secretlamb.RefServiceSecret, Name: "arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3"}, ref)
+
+	_, err = secretlamb.ParseRef("foo")
+	assert.ErrorContains(err, `invalid reference "foo"`)
+
+	_, err = secretlamb.ParseRef("s3:foo")
+	assert.ErrorContains(err, `unknown service "s3"`)
+}
+
+func TestParseRefs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	refs, err := secretlamb.ParseRefs("ssm:/a, secret:b,")
+	require.NoError(err)
+	assert.Equal([]secretlamb.Ref{
+		{Service: secretlamb.RefServiceParameter, Name: "/a"},
+		{Service: secretlamb.RefServiceSecret, Name: "b"},
+	}, refs)
+
+	refs, err = secretlamb.ParseRefs("")
+	require.NoError(err)
+	assert.Empty(refs)
+}
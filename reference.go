@@ -0,0 +1,75 @@
+package secretlamb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const SecretsManagerReferencePrefix = "/aws/reference/secretsmanager/"
+
+func SecretReferenceName(secretId string) string {
+	return SecretsManagerReferencePrefix + secretId
+}
+
+// GetSecretReference reads a Secrets Manager secret through Parameter Store, which only needs SSM permissions.
+// Decryption is always requested because Parameter Store requires it for references.
+func (p *Parameters) GetSecretReference(ctx context.Context, secretId string, options ...*ParameterOption) (*SecretOutput, error) {
+	options = append([]*ParameterOption{ParameterWithDecryption()}, options...)
+	output, err := p.GetWithContext(ctx, SecretReferenceName(secretId), options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := secretFromReference(secretId, &output.Parameter)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret reference - %w", err)
+	}
+
+	return secret, nil
+}
+
+// Parameter Store returns the Secrets Manager response in SourceResult, with CreatedDate as a number.
+type referenceSourceResult struct {
+	Arn           string          `json:"ARN"`
+	Name          string          `json:"Name"`
+	VersionID     string          `json:"VersionId"`
+	SecretString  string          `json:"SecretString"`
+	VersionStages []string        `json:"VersionStages"`
+	CreatedDate   json.RawMessage `json:"CreatedDate"`
+}
+
+func secretFromReference(secretId string, param *ParameterOutputParameter) (*SecretOutput, error) {
+	if param.SourceResult == "" {
+		secret := &SecretOutput{
+			Name:         secretId,
+			SecretString: param.Value,
+		}
+
+		return secret, nil
+	}
+
+	src := &referenceSourceResult{}
+
+	if err := json.Unmarshal([]byte(param.SourceResult), src); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+
+	secret := &SecretOutput{
+		Arn:           src.Arn,
+		Name:          src.Name,
+		VersionID:     src.VersionID,
+		SecretString:  src.SecretString,
+		VersionStages: src.VersionStages,
+		CreatedDate:   strings.Trim(string(src.CreatedDate), `"`),
+	}
+
+	if secret.SecretString == "" {
+		secret.SecretString = param.Value
+	}
+
+	return secret, nil
+}
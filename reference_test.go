@@ -0,0 +1,135 @@
+package secretlamb_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func referenceResponse(t *testing.T) string {
+	body, err := json.Marshal(map[string]any{
+		"Parameter": map[string]any{
+			"Name":             "/aws/reference/secretsmanager/prod/db",
+			"Type":             "SecureString",
+			"Value":            `{"password":"p@ss"}`,
+			"Version":          0,
+			"LastModifiedDate": "1530018761.888",
+			"ARN":              "arn:aws:ssm:us-west-2:123456789012:parameter/aws/reference/secretsmanager/prod/db",
+			"DataType":         "text",
+			"SourceResult":     `{"CreatedDate":1.523477145713E9,"Name":"prod/db","ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db-a1b2c3","VersionId":"a1b2c3d4","SecretString":"{\"password\":\"p@ss\"}","VersionStages":["AWSCURRENT"]}`,
+		},
+	})
+
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestGetSecretReference(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Faws%2Freference%2Fsecretsmanager%2Fprod%2Fdb&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, referenceResponse(t)))
+
+	assert.Equal("/aws/reference/secretsmanager/prod/db", secretlamb.SecretReferenceName("prod/db"))
+
+	p := secretlamb.MustNewParameters()
+	value, err := p.GetSecretReference(context.Background(), "prod/db")
+	require.NoError(err)
+
+	assert.Equal(
+		&secretlamb.SecretOutput{
+			Arn:           "arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db-a1b2c3",
+			Name:          "prod/db",
+			VersionID:     "a1b2c3d4",
+			SecretString:  `{"password":"p@ss"}`,
+			VersionStages: []string{"AWSCURRENT"},
+			CreatedDate:   "1.523477145713E9",
+		},
+		value,
+	)
+}
+
+func TestGetSecretReferenceWithoutSourceResult(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Faws%2Freference%2Fsecretsmanager%2Fprod%2Fdb&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "p@ss")))
+
+	p := secretlamb.MustNewParameters()
+	value, err := p.GetSecretReference(context.Background(), "prod/db")
+	require.NoError(err)
+	assert.Equal(&secretlamb.SecretOutput{Name: "prod/db", SecretString: "p@ss"}, value)
+}
+
+func TestSecretsWithParameterStoreFallback(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusBadRequest, "AccessDeniedException: not authorized to perform: secretsmanager:GetSecretValue"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb&versionStage=AWSPREVIOUS", httpmock.NewStringResponder(http.StatusBadRequest, "AccessDeniedException: not authorized to perform: secretsmanager:GetSecretValue"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fapi", httpmock.NewStringResponder(http.StatusBadRequest, "ResourceNotFoundException"))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Faws%2Freference%2Fsecretsmanager%2Fprod%2Fdb&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, referenceResponse(t)))
+
+	s := secretlamb.MustNewSecrets().WithParameterStoreFallback(secretlamb.MustNewParameters())
+	value, err := s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal(`{"password":"p@ss"}`, value.SecretString)
+	assert.Equal("a1b2c3d4", value.VersionID)
+
+	_, err = s.Get("prod/db", secretlamb.SecretVersionStage("AWSPREVIOUS"))
+	assert.ErrorIs(err, secretlamb.ErrAccessDenied)
+
+	_, err = s.Get("prod/api")
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.Equal(4, httpmock.GetTotalCallCount())
+}
+
+func TestSecretsWithParameterStoreFallbackWithRetry(t *testing.T) {
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "12780")
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	calls := map[string]int{}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+
+		switch r.URL.Path {
+		case "/secretsmanager/get":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "AccessDeniedException: not authorized to perform: secretsmanager:GetSecretValue")
+		case "/systemsmanager/parameters/get/":
+			fmt.Fprint(w, referenceResponse(t))
+		}
+	})
+
+	l, _ := net.Listen("tcp", ":12780")
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	s := secretlamb.MustNewSecrets().WithRetry(1).WithParameterStoreFallback(secretlamb.MustNewParameters().WithRetry(1))
+	value, err := s.Get("prod/db")
+	require.NoError(err)
+	assert.Equal(`{"password":"p@ss"}`, value.SecretString)
+	assert.Equal(map[string]int{"/secretsmanager/get": 1, "/systemsmanager/parameters/get/": 1}, calls)
+}
@@ -0,0 +1,108 @@
+package secretlamb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type Resolved struct {
+	Ref     Ref
+	Value   string
+	Version string
+}
+
+// Resolver reads refs such as "ssm:/app/host" or "secret:prod/db#password".
+// Parameters are read with decryption.
+type Resolver struct {
+	Parameters ParameterGetter
+	Secrets    SecretGetter
+}
+
+func NewResolver(p ParameterGetter, s SecretGetter) *Resolver {
+	return &Resolver{Parameters: p, Secrets: s}
+}
+
+func (r *Resolver) Resolve(ctx context.Context, ref Ref) (*Resolved, error) {
+	switch ref.Service {
+	case RefServiceParameter:
+		output, err := r.Parameters.GetWithContext(ctx, ref.Name, ParameterWithDecryption())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Resolved{Ref: ref, Value: output.Parameter.Value, Version: strconv.FormatInt(output.Parameter.Version, 10)}, nil
+	case RefServiceSecret:
+		output, err := r.Secrets.GetWithContext(ctx, ref.Name, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Resolved{Ref: ref, Value: output.SecretString, Version: output.VersionID}, nil
+	default:
+		return nil, fmt.Errorf("unknown service %q", ref.Service)
+	}
+}
+
+func (r *Resolver) ResolveString(ctx context.Context, s string) (string, error) {
+	ref, err := ParseRef(s)
+
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := r.Resolve(ctx, ref)
+
+	if err != nil {
+		return "", err
+	}
+
+	return resolved.Value, nil
+}
+
+// NewProviderResolver uses the extension in Lambda and local otherwise (see InLambda).
+func NewProviderResolver(local LocalSource) (*Resolver, error) {
+	p, err := NewParameterGetter(local)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSecretGetter(local)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewResolver(p, s), nil
+}
+
+// DefaultResolver is used by package-level helpers such as ApplyEnv.
+// When nil, a resolver from NewProviderResolver(&EnvSource{}) is used, with DefaultCache in Lambda.
+var DefaultResolver *Resolver
+
+func defaultResolver() (*Resolver, error) {
+	if DefaultResolver != nil {
+		return DefaultResolver, nil
+	}
+
+	if !InLambda() {
+		return NewProviderResolver(&EnvSource{})
+	}
+
+	p, err := NewParameters()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSecrets()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewResolver(p.WithCache(DefaultCache), s.WithCache(DefaultCache)), nil
+}
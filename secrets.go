@@ -3,14 +3,19 @@ package secretlamb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
+	"time"
 
-	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Secrets struct {
 	*client
+	referenceFallback *Parameters
 }
 
 type SecretOutput struct {
@@ -25,6 +30,8 @@ type SecretOutput struct {
 type SecretOption struct {
 	Key   string
 	Value string
+	// transform is applied to the output instead of being sent to the extension.
+	transform func(ctx context.Context, output *SecretOutput) error
 }
 
 func SecretVersionId(versionId string) *SecretOption {
@@ -42,7 +49,7 @@ func SecretVersionStage(versionStage string) *SecretOption {
 }
 
 func NewSecrets() (*Secrets, error) {
-	client, err := newClient("/secretsmanager/get")
+	client, err := newClient(ServiceSecrets, "secretId", "/secretsmanager/get")
 	return &Secrets{client: client}, err
 }
 
@@ -57,10 +64,68 @@ func MustNewSecrets() *Secrets {
 }
 
 func (s *Secrets) WithRetry(retryMax int) *Secrets {
-	retryClient := retryablehttp.NewClient()
-	retryClient.RetryMax = retryMax
-	retryClient.CheckRetry = retryPolicy
-	s.HTTPClient = retryClient.StandardClient()
+	s.withRetry(retryMax)
+	return s
+}
+
+// WithDeadlineBudget stops requests (including retries) safety before the deadline of the context,
+// and limits each attempt to attemptTimeout (zero means no per-attempt limit).
+func (s *Secrets) WithDeadlineBudget(safety time.Duration, attemptTimeout time.Duration) *Secrets {
+	s.withDeadlineBudget(safety, attemptTimeout)
+	return s
+}
+
+func (s *Secrets) WithCache(cache *Cache) *Secrets {
+	s.cache = cache
+	return s
+}
+
+func (s *Secrets) WithTracerProvider(tp trace.TracerProvider) *Secrets {
+	s.withTracerProvider(tp)
+	return s
+}
+
+func (s *Secrets) WithMeterProvider(mp metric.MeterProvider) *Secrets {
+	s.withMeterProvider(mp)
+	return s
+}
+
+func (s *Secrets) WithEMF(emitter *EMFEmitter) *Secrets {
+	s.withObserver(emitter)
+	return s
+}
+
+func (s *Secrets) WithObserver(o Observer) *Secrets {
+	s.withObserver(o)
+	return s
+}
+
+func (s *Secrets) WithLogger(logger *slog.Logger, options *LogOptions) *Secrets {
+	s.withLogger(logger, options)
+	return s
+}
+
+func (s *Secrets) WithAuditRecorder(recorder *AuditRecorder) *Secrets {
+	s.auditor = recorder
+	return s
+}
+
+// WithParameterStoreFallback reads the secret through /aws/reference/secretsmanager/<secret-id> with p
+// when Secrets Manager denies access. It is not used when version options are given.
+func (s *Secrets) WithParameterStoreFallback(p *Parameters) *Secrets {
+	s.referenceFallback = p
+	return s
+}
+
+// WithDecrypter decrypts every secret string with d before other options such as SecretDecode are applied.
+func (s *Secrets) WithDecrypter(d Decrypter) *Secrets {
+	s.decrypter = d
+	return s
+}
+
+// WithLockfile applies the version ID locked in l unless a version ID or stage option is given.
+func (s *Secrets) WithLockfile(l *Lockfile) *Secrets {
+	s.lockfile = l
 	return s
 }
 
@@ -69,6 +134,16 @@ func (s *Secrets) Get(secretId string, options ...*SecretOption) (*SecretOutput,
 }
 
 func (s *Secrets) GetWithContext(ctx context.Context, secretId string, options []*SecretOption) (*SecretOutput, error) {
+	secretId, options, transforms := splitSecretOptions(secretId, options)
+
+	if s.lockfile != nil {
+		options = s.lockfile.secretOptions(secretId, s.canonicalName, options)
+	}
+
+	if d := s.clientDecrypter(ctx); d != nil {
+		transforms = append([]*SecretOption{SecretDecrypt(d)}, transforms...)
+	}
+
 	query := &url.Values{}
 	query.Add("secretId", secretId)
 
@@ -76,6 +151,30 @@ func (s *Secrets) GetWithContext(ctx context.Context, secretId string, options [
 		query.Add(opt.Key, opt.Value)
 	}
 
+	output, err := s.getSecret(ctx, query)
+
+	if err != nil && s.referenceFallback != nil && len(options) == 0 && errors.Is(err, ErrAccessDenied) {
+		output, err = s.referenceFallback.GetSecretReference(ctx, secretId)
+	}
+
+	if s.auditor != nil {
+		s.auditSecret(ctx, query, output, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = applySecretTransforms(ctx, secretId, output, transforms)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret - %w", err)
+	}
+
+	return output, nil
+}
+
+func (s *Secrets) getSecret(ctx context.Context, query *url.Values) (*SecretOutput, error) {
 	body, err := s.get(ctx, query)
 
 	if err != nil {
@@ -86,6 +185,7 @@ func (s *Secrets) GetWithContext(ctx context.Context, secretId string, options [
 	err = json.Unmarshal(body, output)
 
 	if err != nil {
+		s.logUnmarshalError(ctx, query, err)
 		return nil, fmt.Errorf("failed to get secret - json unmarshal error: %w", err)
 	}
 
@@ -203,6 +203,7 @@ func TestSecretsGetWithRetry(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if try < 2 {
 			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "not ready to serve traffic, please wait")
 			try++
 		} else {
 			fmt.Fprintln(w, `
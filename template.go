@@ -0,0 +1,89 @@
+package secretlamb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// FuncMap provides ssm, ssmSecure, secret and secretJSON to templates:
+//
+//	host={{ ssm "/app/host" }}
+//	password={{ secret "prod/db#password" }}
+//	user={{ (secretJSON "prod/db").username }}
+func (r *Resolver) FuncMap(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"ssm": func(name string) (string, error) {
+			output, err := r.Parameters.GetWithContext(ctx, name)
+
+			if err != nil {
+				return "", err
+			}
+
+			return output.Parameter.Value, nil
+		},
+		"ssmSecure": func(name string) (string, error) {
+			output, err := r.Parameters.GetWithContext(ctx, name, ParameterWithDecryption())
+
+			if err != nil {
+				return "", err
+			}
+
+			return output.Parameter.Value, nil
+		},
+		"secret": func(secretId string) (string, error) {
+			output, err := r.Secrets.GetWithContext(ctx, secretId, nil)
+
+			if err != nil {
+				return "", err
+			}
+
+			return output.SecretString, nil
+		},
+		"secretJSON": func(secretId string) (any, error) {
+			output, err := r.Secrets.GetWithContext(ctx, secretId, nil)
+
+			if err != nil {
+				return nil, err
+			}
+
+			var value any
+			err = json.Unmarshal([]byte(output.SecretString), &value)
+
+			if err != nil {
+				// The payload must not appear in the error.
+				return nil, fmt.Errorf("secret %s is not JSON", secretId)
+			}
+
+			return value, nil
+		},
+	}
+}
+
+// Render executes tmpl with FuncMap and writes the result to w.
+// Nothing is written when a value cannot be read.
+func (r *Resolver) Render(ctx context.Context, tmpl string, w io.Writer) error {
+	t, err := template.New("secretlamb").Option("missingkey=error").Funcs(r.FuncMap(ctx)).Parse(tmpl)
+
+	if err != nil {
+		return fmt.Errorf("failed to render template - parse error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to render template - execute error: %w", err)
+	}
+
+	_, err = buf.WriteTo(w)
+
+	if err != nil {
+		return fmt.Errorf("failed to render template - write error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package secretlamb_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func TestResolverRender(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fhost", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "db.example.com")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=%2Fapp%2Fkey&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, "s3cr3t")))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=prod%2Fdb", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", `{"username":"scott","password":"tiger"}`)))
+
+	r := secretlamb.NewResolver(secretlamb.MustNewParameters(), secretlamb.MustNewSecrets())
+	tmpl := `host={{ ssm "/app/host" }}
+key={{ ssmSecure "/app/key" }}
+password={{ secret "prod/db#password" }}
+{{ with secretJSON "prod/db" }}user={{ .username }}{{ end }}
+`
+
+	var buf bytes.Buffer
+	err := r.Render(context.Background(), tmpl, &buf)
+	require.NoError(err)
+	assert.Equal("host=db.example.com\nkey=s3cr3t\npassword=tiger\nuser=scott\n", buf.String())
+}
+
+func TestResolverRenderErr(t *testing.T) {
+	assert := assert.New(t)
+
+	r := secretlamb.NewResolver(
+		secretlamb.NewFakeParameters(map[string]string{"/app/host": "db.example.com"}),
+		secretlamb.NewFakeSecrets(map[string]string{"plain": "tiger"}),
+	)
+
+	var buf bytes.Buffer
+	err := r.Render(context.Background(), `{{ ssm "/app/host" }} {{ ssm "/missing" }}`, &buf)
+	assert.ErrorIs(err, secretlamb.ErrNotFound)
+	assert.Empty(buf.String())
+
+	err = r.Render(context.Background(), `{{ secretJSON "plain" }}`, &buf)
+	assert.ErrorContains(err, "secret plain is not JSON")
+	assert.NotContains(err.Error(), "tiger")
+
+	err = r.Render(context.Background(), `{{ ssm }`, &buf)
+	assert.ErrorContains(err, "failed to render template - parse error")
+}
@@ -0,0 +1,271 @@
+package secretlamb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type PEMSource interface {
+	FetchPEM(ctx context.Context) (pem []byte, version string, err error)
+}
+
+type secretPEM struct {
+	secrets  SecretGetter
+	secretId string
+	options  []*SecretOption
+}
+
+func SecretPEM(s SecretGetter, secretId string, options ...*SecretOption) PEMSource {
+	return &secretPEM{secrets: s, secretId: secretId, options: options}
+}
+
+func (src *secretPEM) FetchPEM(ctx context.Context) ([]byte, string, error) {
+	output, err := src.secrets.GetWithContext(ctx, src.secretId, src.options)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(output.SecretString), output.VersionID, nil
+}
+
+type parameterPEM struct {
+	parameters ParameterGetter
+	name       string
+	options    []*ParameterOption
+}
+
+// ParameterPEM always requests decryption so that SecureString parameters can hold private keys.
+func ParameterPEM(p ParameterGetter, name string, options ...*ParameterOption) PEMSource {
+	options = append([]*ParameterOption{ParameterWithDecryption()}, options...)
+	return &parameterPEM{parameters: p, name: name, options: options}
+}
+
+func (src *parameterPEM) FetchPEM(ctx context.Context) ([]byte, string, error) {
+	output, err := src.parameters.GetWithContext(ctx, src.name, src.options...)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(output.Parameter.Value), strconv.FormatInt(output.Parameter.Version, 10), nil
+}
+
+func LoadCertificate(ctx context.Context, cert PEMSource, key PEMSource) (*tls.Certificate, error) {
+	certificate, _, err := loadCertificate(ctx, cert, key)
+	return certificate, err
+}
+
+func loadCertificate(ctx context.Context, cert PEMSource, key PEMSource) (*tls.Certificate, string, error) {
+	certPEM, keyPEM, version, err := fetchKeyPair(ctx, cert, key)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load certificate - %w", err)
+	}
+
+	certificate, err := parseKeyPair(certPEM, keyPEM)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load certificate - %w", err)
+	}
+
+	return certificate, version, nil
+}
+
+func fetchKeyPair(ctx context.Context, cert PEMSource, key PEMSource) ([]byte, []byte, string, error) {
+	certPEM, certVersion, err := cert.FetchPEM(ctx)
+
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("fetch certificate error: %w", err)
+	}
+
+	keyPEM, keyVersion, err := key.FetchPEM(ctx)
+
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("fetch key error: %w", err)
+	}
+
+	return certPEM, keyPEM, certVersion + "/" + keyVersion, nil
+}
+
+func parseKeyPair(certPEM []byte, keyPEM []byte) (*tls.Certificate, error) {
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+
+	if err != nil {
+		return nil, fmt.Errorf("x509 key pair error: %w", err)
+	}
+
+	if certificate.Leaf == nil {
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("x509 parse error: %w", err)
+		}
+	}
+
+	return &certificate, nil
+}
+
+func LoadCertPool(ctx context.Context, sources ...PEMSource) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, src := range sources {
+		pem, _, err := src.FetchPEM(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cert pool - fetch certificate error: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to load cert pool - no certificates found in PEM")
+		}
+	}
+
+	return pool, nil
+}
+
+func CertificateExpiry(certificate *tls.Certificate) (time.Time, error) {
+	if certificate.Leaf != nil {
+		return certificate.Leaf.NotAfter, nil
+	}
+
+	if len(certificate.Certificate) == 0 {
+		return time.Time{}, errors.New("failed to get certificate expiry - empty certificate chain")
+	}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get certificate expiry - x509 parse error: %w", err)
+	}
+
+	return leaf.NotAfter, nil
+}
+
+// DefaultCertificateCheckInterval is the MinCheckInterval of the reloader used by NewTLSConfig.
+var DefaultCertificateCheckInterval = time.Minute
+
+// CertificateReloader keeps serving the last good certificate when the sources cannot be fetched or parsed,
+// so a brief extension error does not fail TLS handshakes.
+type CertificateReloader struct {
+	cert PEMSource
+	key  PEMSource
+	// MinCheckInterval limits how often the sources are fetched to look for a new version, including after errors.
+	// Zero checks on every call.
+	MinCheckInterval time.Duration
+	mu               sync.Mutex
+	certificate      *tls.Certificate
+	version          string
+	checkedAt        time.Time
+}
+
+func NewCertificateReloader(ctx context.Context, cert PEMSource, key PEMSource) (*CertificateReloader, error) {
+	certificate, version, err := loadCertificate(ctx, cert, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reloader := &CertificateReloader{
+		cert:        cert,
+		key:         key,
+		certificate: certificate,
+		version:     version,
+		checkedAt:   time.Now(),
+	}
+
+	return reloader, nil
+}
+
+func (r *CertificateReloader) Certificate(ctx context.Context) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.MinCheckInterval > 0 && time.Since(r.checkedAt) < r.MinCheckInterval {
+		return r.certificate, nil
+	}
+
+	r.checkedAt = time.Now()
+	certPEM, keyPEM, version, err := fetchKeyPair(ctx, r.cert, r.key)
+
+	if err != nil || version == r.version {
+		return r.certificate, nil
+	}
+
+	certificate, err := parseKeyPair(certPEM, keyPEM)
+
+	if err != nil {
+		return r.certificate, nil
+	}
+
+	r.certificate = certificate
+	r.version = version
+
+	return certificate, nil
+}
+
+func (r *CertificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(contextOrBackground(hello.Context()))
+}
+
+func (r *CertificateReloader) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(contextOrBackground(info.Context()))
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+
+	return ctx
+}
+
+func (r *CertificateReloader) Version() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version
+}
+
+func (r *CertificateReloader) NotAfter() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.certificate.Leaf.NotAfter
+}
+
+// NewTLSConfig returns a config usable on both sides of an mTLS connection:
+// the certificate is served through GetCertificate/GetClientCertificate and rootCAs is used for RootCAs and ClientCAs.
+// New versions of the certificate are looked for at most every DefaultCertificateCheckInterval.
+func NewTLSConfig(ctx context.Context, cert PEMSource, key PEMSource, rootCAs ...PEMSource) (*tls.Config, error) {
+	reloader, err := NewCertificateReloader(ctx, cert, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reloader.MinCheckInterval = DefaultCertificateCheckInterval
+
+	config := &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetCertificate:       reloader.GetCertificate,
+		GetClientCertificate: reloader.GetClientCertificate,
+	}
+
+	if len(rootCAs) > 0 {
+		pool, err := LoadCertPool(ctx, rootCAs...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = pool
+		config.ClientCAs = pool
+	}
+
+	return config, nil
+}
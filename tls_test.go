@@ -0,0 +1,207 @@
+package secretlamb_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winebarrel/secretlamb"
+)
+
+func generateTestCertificate(t *testing.T, cn string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return string(certPEM), string(keyPEM)
+}
+
+func secretResponse(t *testing.T, versionID string, secretString string) string {
+	body, err := json.Marshal(map[string]any{
+		"ARN":           "arn:aws:secretsmanager:us-west-2:123456789012:secret:MyTestSecret-a1b2c3",
+		"Name":          "MyTestSecret",
+		"VersionId":     versionID,
+		"SecretString":  secretString,
+		"VersionStages": []string{"AWSCURRENT"},
+		"CreatedDate":   "1523477145.713",
+	})
+
+	require.NoError(t, err)
+	return string(body)
+}
+
+func parameterResponse(t *testing.T, version int64, value string) string {
+	body, err := json.Marshal(map[string]any{
+		"Parameter": map[string]any{
+			"Name":             "MyStringParameter",
+			"Type":             "SecureString",
+			"Value":            value,
+			"Version":          version,
+			"LastModifiedDate": "1530018761.888",
+			"ARN":              "arn:aws:ssm:us-east-2:111222333444:parameter/MyStringParameter",
+			"DataType":         "text",
+		},
+	})
+
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestLoadCertificate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	certPEM, keyPEM := generateTestCertificate(t, "client", notAfter)
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=cert", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", certPEM)))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/systemsmanager/parameters/get/?name=key&withDecryption=true", httpmock.NewStringResponder(http.StatusOK, parameterResponse(t, 1, keyPEM)))
+
+	s := secretlamb.MustNewSecrets()
+	p := secretlamb.MustNewParameters()
+	cert, err := secretlamb.LoadCertificate(context.Background(), secretlamb.SecretPEM(s, "cert"), secretlamb.ParameterPEM(p, "key"))
+	require.NoError(err)
+	assert.Equal("client", cert.Leaf.Subject.CommonName)
+
+	expiry, err := secretlamb.CertificateExpiry(cert)
+	require.NoError(err)
+	assert.Equal(notAfter, expiry.UTC())
+}
+
+func TestLoadCertificateErr(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=cert", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", "not a pem")))
+
+	s := secretlamb.MustNewSecrets()
+	_, err := secretlamb.LoadCertificate(context.Background(), secretlamb.SecretPEM(s, "cert"), secretlamb.SecretPEM(s, "cert"))
+	assert.ErrorContains(err, "failed to load certificate - x509 key pair error")
+}
+
+func TestLoadCertPool(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	caPEM, _ := generateTestCertificate(t, "ca", time.Now().Add(time.Hour))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=ca", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", caPEM)))
+
+	s := secretlamb.MustNewSecrets()
+	pool, err := secretlamb.LoadCertPool(context.Background(), secretlamb.SecretPEM(s, "ca"))
+	require.NoError(err)
+	assert.False(pool.Equal(x509.NewCertPool()))
+}
+
+func TestCertificateReloader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	certPEM1, keyPEM1 := generateTestCertificate(t, "v1", time.Now().Add(time.Hour))
+	certPEM2, keyPEM2 := generateTestCertificate(t, "v2", time.Now().Add(2*time.Hour))
+	version := "v1"
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bundle", func(req *http.Request) (*http.Response, error) {
+		if version == "v1" {
+			return httpmock.NewStringResponse(http.StatusOK, secretResponse(t, "v1", certPEM1+keyPEM1)), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, secretResponse(t, "v2", certPEM2+keyPEM2)), nil
+	})
+
+	s := secretlamb.MustNewSecrets()
+	src := secretlamb.SecretPEM(s, "bundle")
+	reloader, err := secretlamb.NewCertificateReloader(context.Background(), src, src)
+	require.NoError(err)
+
+	cert, err := reloader.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(err)
+	assert.Equal("v1", cert.Leaf.Subject.CommonName)
+	assert.Equal("v1/v1", reloader.Version())
+
+	version = "v2"
+	cert, err = reloader.Certificate(context.Background())
+	require.NoError(err)
+	assert.Equal("v2", cert.Leaf.Subject.CommonName)
+	assert.Equal("v2/v2", reloader.Version())
+	assert.Equal(cert.Leaf.NotAfter, reloader.NotAfter())
+
+	// the last good certificate is kept on errors
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bundle", httpmock.NewStringResponder(http.StatusBadRequest, "ResourceNotFoundException"))
+	cert, err = reloader.Certificate(context.Background())
+	require.NoError(err)
+	assert.Equal("v2", cert.Leaf.Subject.CommonName)
+
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bundle", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v3", "not a PEM")))
+	cert, err = reloader.Certificate(context.Background())
+	require.NoError(err)
+	assert.Equal("v2", cert.Leaf.Subject.CommonName)
+	assert.Equal("v2/v2", reloader.Version())
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	certPEM, keyPEM := generateTestCertificate(t, "client", time.Now().Add(time.Hour))
+	httpmock.RegisterResponder(http.MethodGet, "http://localhost:2773/secretsmanager/get?secretId=bundle", httpmock.NewStringResponder(http.StatusOK, secretResponse(t, "v1", certPEM+keyPEM)))
+
+	s := secretlamb.MustNewSecrets()
+	src := secretlamb.SecretPEM(s, "bundle")
+	config, err := secretlamb.NewTLSConfig(context.Background(), src, src, src)
+	require.NoError(err)
+	assert.NotNil(config.RootCAs)
+	assert.NotNil(config.GetClientCertificate)
+
+	cert, err := config.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(err)
+	assert.Equal("client", cert.Leaf.Subject.CommonName)
+
+	// handshakes within DefaultCertificateCheckInterval do not fetch the sources
+	calls := httpmock.GetTotalCallCount()
+
+	for range 3 {
+		_, err = config.GetCertificate(&tls.ClientHelloInfo{})
+		require.NoError(err)
+	}
+
+	assert.Equal(calls, httpmock.GetTotalCallCount())
+}